@@ -0,0 +1,66 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition describes the state of a resource at a certain point.
+type Condition struct {
+	// Type of condition in CamelCase.
+	Type string `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason for the condition's last transition in CamelCase.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SetCondition adds or updates the condition of the given type. The last
+// transition time is kept if the status didn't change.
+func SetCondition(conditions *[]Condition, cond Condition) {
+	cond.LastTransitionTime = metav1.Now()
+
+	for i, c := range *conditions {
+		if c.Type != cond.Type {
+			continue
+		}
+		if c.Status == cond.Status {
+			cond.LastTransitionTime = c.LastTransitionTime
+		}
+		(*conditions)[i] = cond
+		return
+	}
+
+	*conditions = append(*conditions, cond)
+}
+
+// FindCondition returns the condition of the given type or nil.
+func FindCondition(conditions []Condition, condType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == condType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
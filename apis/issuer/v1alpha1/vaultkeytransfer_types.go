@@ -0,0 +1,205 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterKind is the kind of a database cluster.
+// +kubebuilder:validation:Enum=PerconaXtraDBCluster;PerconaServerMongoDB
+type ClusterKind string
+
+const (
+	ClusterKindPerconaXtraDBCluster ClusterKind = "PerconaXtraDBCluster"
+	// ClusterKindPerconaServerMongoDB clusters transfer master keys of their
+	// replica sets, backup UID filters are not supported for them.
+	ClusterKindPerconaServerMongoDB ClusterKind = "PerconaServerMongoDB"
+)
+
+// ClusterReference points to a database cluster.
+type ClusterReference struct {
+	// Kind is PerconaXtraDBCluster by default.
+	// +optional
+	Kind ClusterKind `json:"kind,omitempty"`
+	Name string      `json:"name"`
+	// Namespace is the transfer's namespace if not set.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ConflictPolicy defines what happens if a key already exists at the destination.
+// +kubebuilder:validation:Enum=Overwrite;SkipExisting;FailOnDifference
+type ConflictPolicy string
+
+const (
+	ConflictPolicyOverwrite    ConflictPolicy = "Overwrite"
+	ConflictPolicySkipExisting ConflictPolicy = "SkipExisting"
+	// ConflictPolicyFailOnDifference skips identical keys and fails
+	// keys that differ from the existing ones.
+	ConflictPolicyFailOnDifference ConflictPolicy = "FailOnDifference"
+)
+
+// VaultKeyTransferSpec defines the desired state of VaultKeyTransfer
+type VaultKeyTransferSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Source cluster the keys are copied from. It must allow the destination
+	// with the percona.com/allow-transition-key-transfer annotation.
+	Source ClusterReference `json:"source"`
+	// Destination cluster the keys are copied to, it must be of the
+	// same kind as the source and in the transfer's namespace.
+	Destination ClusterReference `json:"destination"`
+	// BackupUIDs limits the transfer to keys of the backups, all keys are
+	// copied if empty.
+	// +optional
+	BackupUIDs []string `json:"backupUIDs,omitempty"`
+	// BackupNames limits the transfer to keys of the source cluster's
+	// PerconaXtraDBClusterBackup objects, their UIDs are added to BackupUIDs.
+	// +optional
+	BackupNames []string `json:"backupNames,omitempty"`
+	// BackupsCreatedAfter limits the transfer to keys of the source cluster's
+	// PerconaXtraDBClusterBackup objects created at or after the time.
+	// +optional
+	BackupsCreatedAfter *metav1.Time `json:"backupsCreatedAfter,omitempty"`
+	// BackupsCreatedBefore limits the transfer to keys of the source cluster's
+	// PerconaXtraDBClusterBackup objects created before the time.
+	// +optional
+	BackupsCreatedBefore *metav1.Time `json:"backupsCreatedBefore,omitempty"`
+	// ConflictPolicy is Overwrite by default for transition keys and
+	// SkipExisting for master keys.
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+	// AllVersions copies every version of keys in a KV version 2 engine
+	// rather than only the current one.
+	// +optional
+	AllVersions bool `json:"allVersions,omitempty"`
+	// DryRun reads and compares keys and reports the keys that would be
+	// copied as Planned, nothing is written.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// Move deletes transition keys from the source once the destination is
+	// read back and holds them. The source must allow the destination with
+	// the percona.com/allow-transition-key-move annotation as well. Keys of
+	// a KV version 2 engine can be moved only with AllVersions.
+	// +optional
+	Move bool `json:"move,omitempty"`
+}
+
+// TransferPhase is the overall state of a transfer.
+type TransferPhase string
+
+const (
+	TransferPhasePending   TransferPhase = "Pending"
+	TransferPhaseRunning   TransferPhase = "Running"
+	TransferPhaseSucceeded TransferPhase = "Succeeded"
+	TransferPhaseFailed    TransferPhase = "Failed"
+)
+
+// KeyResult is what happened to a transition key.
+type KeyResult string
+
+const (
+	KeyResultCopied  KeyResult = "Copied"
+	KeyResultSkipped KeyResult = "Skipped"
+	KeyResultFailed  KeyResult = "Failed"
+	// KeyResultPlanned is reported by dry runs.
+	KeyResultPlanned KeyResult = "Planned"
+	// KeyResultMoved is reported by moves for keys deleted from the source.
+	KeyResultMoved KeyResult = "Moved"
+)
+
+// TransferredKey describes the transfer of a single key.
+type TransferredKey struct {
+	// Key is the backup UID of a transition key or the path of a master key
+	// relative to the cluster's Vault path.
+	Key    string    `json:"key"`
+	Result KeyResult `json:"result"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Checksum is the SHA-256 of the source key's current version in its
+	// canonical JSON encoding. Copied keys are read back from the destination
+	// and verified to have it.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// VaultKeyTransferStatus defines the observed state of VaultKeyTransfer
+type VaultKeyTransferStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Phase is Pending until the keys are transferred and Running while
+	// they are. The transfer is Failed if any of the keys failed, it isn't
+	// retried then. A transfer interrupted by an operator restart stays
+	// Running and has to be recreated.
+	// +optional
+	Phase TransferPhase `json:"phase,omitempty"`
+	// Progress of a running transfer as processed/all keys.
+	// +optional
+	Progress string `json:"progress,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	Keys []TransferredKey `json:"keys,omitempty"`
+	// +optional
+	Copied int `json:"copied"`
+	// +optional
+	Skipped int `json:"skipped"`
+	// +optional
+	Failed int `json:"failed"`
+	// +optional
+	Planned int `json:"planned"`
+	// +optional
+	Moved int `json:"moved"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ConditionTransferred is True once all keys are transferred.
+const ConditionTransferred = "Transferred"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.name`
+// +kubebuilder:printcolumn:name="Destination",type=string,JSONPath=`.spec.destination.name`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Progress",type=string,JSONPath=`.status.progress`
+// +kubebuilder:printcolumn:name="Copied",type=integer,JSONPath=`.status.copied`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultKeyTransfer is the Schema for the vaultkeytransfers API
+type VaultKeyTransfer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultKeyTransferSpec   `json:"spec,omitempty"`
+	Status VaultKeyTransferStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultKeyTransferList contains a list of VaultKeyTransfer
+type VaultKeyTransferList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultKeyTransfer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultKeyTransfer{}, &VaultKeyTransferList{})
+}
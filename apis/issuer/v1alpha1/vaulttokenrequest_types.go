@@ -0,0 +1,134 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AuthMode defines what is issued for the request.
+// +kubebuilder:validation:Enum=Token;KubernetesRole
+type AuthMode string
+
+const (
+	// AuthModeToken issues a Vault token.
+	AuthModeToken AuthMode = "Token"
+	// AuthModeKubernetesRole creates a Vault Kubernetes auth role bound to
+	// the request's ServiceAccount, no token is issued.
+	AuthModeKubernetesRole AuthMode = "KubernetesRole"
+)
+
+// OutputFormat defines the layout of the issued Secret.
+// +kubebuilder:validation:Enum=KeyringVault;Plain
+type OutputFormat string
+
+const (
+	// OutputFormatKeyringVault writes keyring_vault.conf and ca.cert used by
+	// the keyring_vault plugin.
+	OutputFormatKeyringVault OutputFormat = "KeyringVault"
+	// OutputFormatPlain writes every value under its own key: token (or
+	// vault_auth_path and vault_role), vault_url, secret_path, kv_version
+	// and ca.crt, the layout of PerconaPGCluster secrets.
+	OutputFormatPlain OutputFormat = "Plain"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// VaultTokenRequestSpec defines the desired state of VaultTokenRequest
+type VaultTokenRequestSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// SecretName is the Secret in the request's namespace the result is written to.
+	SecretName string `json:"secretName"`
+
+	// PolicyTemplate is a Go template of the Vault policy bound to the token.
+	// {{ .Path }} is <mount>/<namespace>/<secretName>, {{ .Namespace }} and
+	// {{ .Name }} are the request's namespace and name. Policy paths must be
+	// within {{ .Path }}. The default policy grants full access to {{ .Path }}.
+	// +optional
+	PolicyTemplate string `json:"policyTemplate,omitempty"`
+
+	// TTL of the token, the operator default if not set.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// MaxTTL is the explicit max TTL of the token.
+	// +optional
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+	// Period makes the token periodic.
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
+
+	// AuthMode is Token by default.
+	// +optional
+	AuthMode AuthMode `json:"authMode,omitempty"`
+	// ServiceAccountName the role is bound to with the KubernetesRole auth
+	// mode, "default" if not set.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// OutputFormat is KeyringVault by default.
+	// +optional
+	OutputFormat OutputFormat `json:"outputFormat,omitempty"`
+}
+
+// VaultTokenRequestStatus defines the observed state of VaultTokenRequest
+type VaultTokenRequestStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// TokenAccessor is the accessor of the token written to the Secret.
+	// +optional
+	TokenAccessor string `json:"tokenAccessor,omitempty"`
+	// ExpirationTime of the token, not set for tokens without TTL.
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ConditionReady is True once the Secret contains valid credentials.
+const ConditionReady = "Ready"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.spec.secretName`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Expires",type=string,JSONPath=`.status.expirationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultTokenRequest is the Schema for the vaulttokenrequests API
+type VaultTokenRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultTokenRequestSpec   `json:"spec,omitempty"`
+	Status VaultTokenRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultTokenRequestList contains a list of VaultTokenRequest
+type VaultTokenRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultTokenRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultTokenRequest{}, &VaultTokenRequestList{})
+}
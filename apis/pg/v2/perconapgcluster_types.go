@@ -0,0 +1,58 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// PerconaPGClusterSpec defines the desired state of PerconaPGCluster
+type PerconaPGClusterSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+}
+
+// PerconaPGClusterStatus defines the observed state of PerconaPGCluster
+type PerconaPGClusterStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// PerconaPGCluster is the Schema for the perconapgclusters API
+type PerconaPGCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PerconaPGClusterSpec   `json:"spec,omitempty"`
+	Status PerconaPGClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PerconaPGClusterList contains a list of PerconaPGCluster
+type PerconaPGClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PerconaPGCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PerconaPGCluster{}, &PerconaPGClusterList{})
+}
@@ -25,6 +25,15 @@ import (
 // PerconaServerMongoDBSpec defines the desired state of PerconaServerMongoDB
 type PerconaServerMongoDBSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
+
+	Secrets *SecretsSpec `json:"secrets,omitempty"`
+}
+
+// SecretsSpec holds names of the cluster's Secrets.
+type SecretsSpec struct {
+	// Vault is the Secret with the Vault token and settings of the data at
+	// rest encryption.
+	Vault string `json:"vault,omitempty"`
 }
 
 // PerconaServerMongoDBStatus defines the observed state of PerconaServerMongoDB
@@ -0,0 +1,55 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PerconaXtraDBClusterBackupSpec defines the desired state of PerconaXtraDBClusterBackup
+type PerconaXtraDBClusterBackupSpec struct {
+	PXCCluster string `json:"pxcCluster,omitempty"`
+}
+
+// PerconaXtraDBClusterBackupStatus defines the observed state of PerconaXtraDBClusterBackup
+type PerconaXtraDBClusterBackupStatus struct {
+	State string `json:"state,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PerconaXtraDBClusterBackup is the Schema for the perconaxtradbclusterbackups API
+type PerconaXtraDBClusterBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PerconaXtraDBClusterBackupSpec   `json:"spec,omitempty"`
+	Status PerconaXtraDBClusterBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PerconaXtraDBClusterBackupList contains a list of PerconaXtraDBClusterBackup
+type PerconaXtraDBClusterBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PerconaXtraDBClusterBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PerconaXtraDBClusterBackup{}, &PerconaXtraDBClusterBackupList{})
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	issuerv1alpha1 "github.com/Percona-Lab/k8s-vault-issuer/apis/issuer/v1alpha1"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// policyData is passed to the policy template. DataPath and MetadataPath
+// are where a KV version 2 engine keeps the path, both equal Path for
+// version 1.
+type policyData struct {
+	Path         string
+	DataPath     string
+	MetadataPath string
+	Namespace    string
+	Name         string
+}
+
+func renderPolicy(o *issuerv1alpha1.VaultTokenRequest, format issuer.Format, kv vault.KV, path string) (string, error) {
+	if o.Spec.PolicyTemplate == "" {
+		return format.Policy(kv, path)
+	}
+
+	tmpl, err := template.New("policy").Option("missingkey=error").Parse(o.Spec.PolicyTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "parse policy template")
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, policyData{
+		Path:         path,
+		DataPath:     kv.DataPath(path),
+		MetadataPath: kv.MetadataPath(path),
+		Namespace:    o.Namespace,
+		Name:         o.Name,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "execute policy template")
+	}
+
+	policy := buf.String()
+	allowed := kv.Paths(path)
+	if _, keyring := format.(issuer.KeyringVaultFormat); keyring && kv.Version == 2 {
+		allowed = append(allowed, "sys/mounts/"+kv.Mount)
+	}
+	err = vault.CheckPolicyPaths(policy, allowed...)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid policy")
+	}
+
+	return policy, nil
+}
+
+// outputFormat returns the format the request's Secret is written in.
+func outputFormat(o *issuerv1alpha1.VaultTokenRequest) (issuer.Format, error) {
+	switch o.Spec.OutputFormat {
+	case issuerv1alpha1.OutputFormatKeyringVault, "":
+		return issuer.KeyringVaultFormat{}, nil
+	case issuerv1alpha1.OutputFormatPlain:
+		return issuer.PlainFormat{}, nil
+	}
+	return nil, errors.Errorf("unknown output format %q", o.Spec.OutputFormat)
+}
@@ -0,0 +1,274 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	issuerv1alpha1 "github.com/Percona-Lab/k8s-vault-issuer/apis/issuer/v1alpha1"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+)
+
+// VaultKeyTransferReconciler reconciles a VaultKeyTransfer object
+type VaultKeyTransferReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	Issuer *issuer.Issuer
+	// Clusters returns an empty cluster of the kind, only listed kinds
+	// can be transferred between.
+	Clusters map[issuerv1alpha1.ClusterKind]func() issuer.Cluster
+}
+
+// +kubebuilder:rbac:groups=issuer.percona.com,resources=vaultkeytransfers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=issuer.percona.com,resources=vaultkeytransfers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *VaultKeyTransferReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("vaultkeytransfer", req.NamespacedName)
+
+	rr := reconcile.Result{
+		RequeueAfter: time.Second * 5,
+	}
+
+	o := &issuerv1alpha1.VaultKeyTransfer{}
+	err := r.Client.Get(context.TODO(), req.NamespacedName, o)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return rr, err
+	}
+
+	// a transfer runs once, like a Job
+	switch o.Status.Phase {
+	case issuerv1alpha1.TransferPhaseRunning, issuerv1alpha1.TransferPhaseSucceeded, issuerv1alpha1.TransferPhaseFailed:
+		return reconcile.Result{}, nil
+	}
+
+	if err := validateTransfer(o); err != nil {
+		o.Status.Phase = issuerv1alpha1.TransferPhaseFailed
+		issuerv1alpha1.SetCondition(&o.Status.Conditions, issuerv1alpha1.Condition{
+			Type:    issuerv1alpha1.ConditionTransferred,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Invalid",
+			Message: err.Error(),
+		})
+		uerr := r.Client.Status().Update(context.TODO(), o)
+		if uerr != nil {
+			return rr, errors.Wrap(uerr, "update status")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// the cached object may be older than the transfer's last run, the
+	// optimistic lock makes sure the transfer is started only once
+	orig := o.DeepCopy()
+	o.Status.Phase = issuerv1alpha1.TransferPhaseRunning
+	err = r.Client.Status().Patch(context.TODO(), o, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+	if apierrors.IsConflict(err) {
+		return rr, nil
+	}
+	if err != nil {
+		return rr, errors.Wrap(err, "set running phase")
+	}
+
+	results, err := r.transferKeys(o, log)
+	if err != nil {
+		o.Status.Phase = issuerv1alpha1.TransferPhasePending
+		issuerv1alpha1.SetCondition(&o.Status.Conditions, issuerv1alpha1.Condition{
+			Type:    issuerv1alpha1.ConditionTransferred,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Pending",
+			Message: err.Error(),
+		})
+	} else {
+		setTransferResults(o, results)
+	}
+
+	uerr := r.Client.Status().Update(context.TODO(), o)
+	if uerr != nil {
+		return rr, errors.Wrap(uerr, "update status")
+	}
+	if err != nil {
+		return rr, errors.Wrap(err, "transfer keys")
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func (r *VaultKeyTransferReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&issuerv1alpha1.VaultKeyTransfer{}).
+		Complete(r)
+}
+
+// validateTransfer rejects transfers into other namespaces. Only the source
+// approves a transfer, so a destination in another namespace would let
+// anyone able to create transfers overwrite keys of other teams' clusters.
+func validateTransfer(o *issuerv1alpha1.VaultKeyTransfer) error {
+	if ns := o.Spec.Destination.Namespace; ns != "" && ns != o.Namespace {
+		return errors.Errorf("destination cluster must be in the transfer's namespace %s, not %s", o.Namespace, ns)
+	}
+	return nil
+}
+
+func (r *VaultKeyTransferReconciler) transferKeys(o *issuerv1alpha1.VaultKeyTransfer, log logr.Logger) ([]transfer.KeyResult, error) {
+	src, err := r.getCluster(o.Spec.Source, o.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "get source cluster")
+	}
+	dst, err := r.getCluster(o.Spec.Destination, o.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "get destination cluster")
+	}
+
+	uids, err := r.backupUIDs(o, src)
+	if err != nil {
+		return nil, errors.Wrap(err, "select backups")
+	}
+
+	log.Info("transferring keys")
+	return r.Issuer.TransferKeyResults(src, dst, transfer.Options{
+		BackupUIDs:  uids,
+		Conflict:    transfer.ConflictPolicy(o.Spec.ConflictPolicy),
+		AllVersions: o.Spec.AllVersions,
+		DryRun:      o.Spec.DryRun,
+		Move:        o.Spec.Move,
+		Progress: func(done, total int) {
+			o.Status.Progress = fmt.Sprintf("%d/%d", done, total)
+			err := r.Client.Status().Update(context.TODO(), o)
+			if err != nil {
+				log.Error(err, "can't update progress")
+			}
+		},
+	})
+}
+
+// backupUIDs adds UIDs of the backups selected by names and creation time
+// to the explicit ones.
+func (r *VaultKeyTransferReconciler) backupUIDs(o *issuerv1alpha1.VaultKeyTransfer, src issuer.Cluster) ([]string, error) {
+	sel := issuer.BackupSelector{Names: o.Spec.BackupNames}
+	if o.Spec.BackupsCreatedAfter != nil {
+		sel.CreatedAfter = o.Spec.BackupsCreatedAfter.Time
+	}
+	if o.Spec.BackupsCreatedBefore != nil {
+		sel.CreatedBefore = o.Spec.BackupsCreatedBefore.Time
+	}
+	if sel.Empty() {
+		return o.Spec.BackupUIDs, nil
+	}
+
+	uids, err := r.Issuer.BackupUIDs(src, sel)
+	if err != nil {
+		return nil, err
+	}
+	uids = append(uids, o.Spec.BackupUIDs...)
+	// an empty list would transfer all keys
+	if len(uids) == 0 {
+		return nil, errors.New("no backups match the selection")
+	}
+
+	return uids, nil
+}
+
+func setTransferResults(o *issuerv1alpha1.VaultKeyTransfer, results []transfer.KeyResult) {
+	o.Status.Keys = make([]issuerv1alpha1.TransferredKey, 0, len(results))
+	o.Status.Copied, o.Status.Skipped, o.Status.Failed, o.Status.Planned, o.Status.Moved = 0, 0, 0, 0, 0
+	for _, res := range results {
+		o.Status.Keys = append(o.Status.Keys, issuerv1alpha1.TransferredKey{
+			Key:      res.Key,
+			Result:   issuerv1alpha1.KeyResult(res.Result),
+			Message:  res.Message,
+			Checksum: res.Checksum,
+		})
+		switch res.Result {
+		case transfer.ResultCopied:
+			o.Status.Copied++
+		case transfer.ResultSkipped:
+			o.Status.Skipped++
+		case transfer.ResultFailed:
+			o.Status.Failed++
+		case transfer.ResultPlanned:
+			o.Status.Planned++
+		case transfer.ResultMoved:
+			o.Status.Moved++
+		}
+	}
+
+	now := metav1.Now()
+	o.Status.CompletionTime = &now
+
+	cond := issuerv1alpha1.Condition{
+		Type:    issuerv1alpha1.ConditionTransferred,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: fmt.Sprintf("%d copied, %d skipped", o.Status.Copied, o.Status.Skipped),
+	}
+	if o.Spec.Move {
+		cond.Message = fmt.Sprintf("%d moved, %d skipped", o.Status.Moved, o.Status.Skipped)
+	}
+	if o.Spec.DryRun {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "DryRun"
+		cond.Message = fmt.Sprintf("%d would be copied, %d skipped", o.Status.Planned, o.Status.Skipped)
+	}
+	o.Status.Phase = issuerv1alpha1.TransferPhaseSucceeded
+	if o.Status.Failed > 0 {
+		o.Status.Phase = issuerv1alpha1.TransferPhaseFailed
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Failed"
+		cond.Message = fmt.Sprintf("%d copied, %d moved, %d skipped, %d failed", o.Status.Copied, o.Status.Moved, o.Status.Skipped, o.Status.Failed)
+	}
+	issuerv1alpha1.SetCondition(&o.Status.Conditions, cond)
+}
+
+func (r *VaultKeyTransferReconciler) getCluster(ref issuerv1alpha1.ClusterReference, defaultNamespace string) (issuer.Cluster, error) {
+	kind := ref.Kind
+	if kind == "" {
+		kind = issuerv1alpha1.ClusterKindPerconaXtraDBCluster
+	}
+	newCluster, ok := r.Clusters[kind]
+	if !ok {
+		return nil, errors.Errorf("unknown cluster kind %q", ref.Kind)
+	}
+
+	key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+	if key.Namespace == "" {
+		key.Namespace = defaultNamespace
+	}
+
+	c := newCluster()
+	err := r.Client.Get(context.TODO(), key, c.Object())
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cluster %s.%s", key.Name, key.Namespace)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,496 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	issuerv1alpha1 "github.com/Percona-Lab/k8s-vault-issuer/apis/issuer/v1alpha1"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// vaultTokenRequestFinalizer makes sure the token, role and policy of the
+// request are revoked before the request is gone.
+const vaultTokenRequestFinalizer = "issuer.percona.com/revoke-vault-token"
+
+// requestGenerationAnnotation holds the request's generation the Secret was
+// written for. The Secret, not the request's status, records what was
+// issued, since the status read from the cache may be older than the
+// Secret written by the previous reconcile. The accessor of the token is
+// kept in issuer.TokenAccessorAnnotation, as for clusters.
+const requestGenerationAnnotation = "percona.com/vault-token-request-generation"
+
+// resyncInterval is how often requests are checked when nothing has to be
+// renewed earlier.
+const resyncInterval = time.Hour
+
+// VaultTokenRequestReconciler reconciles a VaultTokenRequest object
+type VaultTokenRequestReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Issuer provides the issuer's own Vault client, the Kubernetes auth
+	// method roles are created in with the KubernetesRole auth mode and
+	// token options used for fields not set in the request.
+	Issuer *issuer.Issuer
+}
+
+// +kubebuilder:rbac:groups=issuer.percona.com,resources=vaulttokenrequests,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=issuer.percona.com,resources=vaulttokenrequests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *VaultTokenRequestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("vaulttokenrequest", req.NamespacedName)
+
+	rr := reconcile.Result{
+		RequeueAfter: time.Second * 5,
+	}
+
+	o := &issuerv1alpha1.VaultTokenRequest{}
+	err := r.Client.Get(context.TODO(), req.NamespacedName, o)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return rr, err
+	}
+
+	if o.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(o, vaultTokenRequestFinalizer) {
+			return reconcile.Result{}, nil
+		}
+
+		log.Info("revoking vault token")
+		err = r.revoke(o)
+		if err != nil {
+			return rr, errors.Wrap(err, "revoke vault token")
+		}
+
+		orig := o.DeepCopy()
+		controllerutil.RemoveFinalizer(o, vaultTokenRequestFinalizer)
+		return reconcile.Result{}, r.Client.Patch(context.TODO(), o, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+	}
+
+	if !controllerutil.ContainsFinalizer(o, vaultTokenRequestFinalizer) {
+		orig := o.DeepCopy()
+		controllerutil.AddFinalizer(o, vaultTokenRequestFinalizer)
+		err = r.Client.Patch(context.TODO(), o, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+		if err != nil {
+			return rr, errors.Wrap(err, "add finalizer")
+		}
+	}
+
+	requeue, err := r.reconcileToken(o, log)
+	if err != nil {
+		issuerv1alpha1.SetCondition(&o.Status.Conditions, issuerv1alpha1.Condition{
+			Type:    issuerv1alpha1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "IssueFailed",
+			Message: err.Error(),
+		})
+	} else {
+		issuerv1alpha1.SetCondition(&o.Status.Conditions, issuerv1alpha1.Condition{
+			Type:    issuerv1alpha1.ConditionReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Issued",
+			Message: fmt.Sprintf("credentials are written to %s secret", o.Spec.SecretName),
+		})
+		o.Status.ObservedGeneration = o.Generation
+		rr.RequeueAfter = requeue
+	}
+
+	uerr := r.Client.Status().Update(context.TODO(), o)
+	if uerr != nil {
+		return rr, errors.Wrap(uerr, "update status")
+	}
+
+	return rr, err
+}
+
+func (r *VaultTokenRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&issuerv1alpha1.VaultTokenRequest{}).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+// reconcileToken makes sure the request's Secret holds valid credentials.
+// It returns when the request should be checked again.
+func (r *VaultTokenRequestReconciler) reconcileToken(o *issuerv1alpha1.VaultTokenRequest, log logr.Logger) (time.Duration, error) {
+	if o.Spec.SecretName == "" {
+		return 0, errors.New("spec.secretName is empty")
+	}
+
+	format, err := outputFormat(o)
+	if err != nil {
+		return 0, err
+	}
+
+	cl, rootVaultConf, err := r.Issuer.Root.Login()
+	if err != nil {
+		return 0, err
+	}
+
+	path := vault.SecretPath(rootVaultConf.SecretMountPoint, o.Namespace, o.Spec.SecretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return 0, err
+	}
+	policy, err := renderPolicy(o, format, kv, path)
+	if err != nil {
+		return 0, err
+	}
+	err = cl.Sys().PutPolicy(policyName(o), policy)
+	if err != nil {
+		return 0, errors.Wrap(err, "put policy")
+	}
+
+	secretObj := &corev1.Secret{}
+	err = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: o.Namespace, Name: o.Spec.SecretName}, secretObj)
+	switch {
+	case apierrors.IsNotFound(err):
+		secretObj = nil
+	case err != nil:
+		return 0, errors.Wrap(err, "get secret")
+	case !metav1.IsControlledBy(secretObj, o):
+		return 0, errors.Errorf("secret %s already exists and isn't owned by the request", o.Spec.SecretName)
+	}
+
+	changed := secretObj == nil || secretObj.Annotations[requestGenerationAnnotation] != strconv.FormatInt(o.Generation, 10)
+	accessor := tokenAccessor(secretObj)
+
+	if authMode(o) == issuerv1alpha1.AuthModeKubernetesRole {
+		if changed {
+			log.Info("writing vault role")
+			err = r.issueRole(cl, rootVaultConf, o, format, path, secretObj)
+			if err != nil {
+				return 0, err
+			}
+		}
+		o.Status.TokenAccessor = ""
+		o.Status.ExpirationTime = nil
+		return resyncInterval, nil
+	}
+
+	if !changed && accessor != "" {
+		requeue, valid, err := r.renewToken(cl, o, format, secretObj, accessor)
+		if err != nil {
+			return 0, err
+		}
+		if valid {
+			return requeue, nil
+		}
+		log.Info("vault token can't be renewed, re-issuing")
+	}
+
+	if changed {
+		// the request could be switched from the KubernetesRole auth mode
+		err = vault.DeleteKubernetesRole(cl, vault.KubernetesAuthPath(r.Issuer.KubernetesAuthPath), policyName(o))
+		if err != nil {
+			return 0, errors.Wrap(err, "delete role")
+		}
+	}
+
+	log.Info("issuing vault token")
+	sec, err := r.issueToken(cl, rootVaultConf, o, format, kv, path, secretObj)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.updateTokenStatus(o, sec.Auth.Accessor, time.Duration(sec.Auth.LeaseDuration)*time.Second), nil
+}
+
+func (r *VaultTokenRequestReconciler) issueToken(cl *api.Client, rootVaultConf vault.Conf, o *issuerv1alpha1.VaultTokenRequest, format issuer.Format, kv vault.KV, path string, secretObj *corev1.Secret) (*api.Secret, error) {
+	prev := tokenAccessor(secretObj)
+	sec, err := vault.CreateToken(cl, policyName(o), r.tokenOptions(o))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := format.SecretData(sec.Auth.ClientToken, rootVaultConf, kv, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.writeSecret(o, secretObj, data, sec.Auth.Accessor)
+	if err != nil {
+		rerr := cl.Auth().Token().RevokeAccessor(sec.Auth.Accessor)
+		if rerr != nil {
+			r.Log.Error(rerr, "can't revoke unused vault token")
+		}
+		return nil, err
+	}
+
+	if prev != "" {
+		err = vault.RevokeAccessor(cl, prev, policyName(o))
+		if err != nil {
+			r.Log.Error(err, "can't revoke replaced vault token", "namespace", o.Namespace, "name", o.Name)
+		}
+	}
+
+	return sec, nil
+}
+
+func (r *VaultTokenRequestReconciler) issueRole(cl *api.Client, rootVaultConf vault.Conf, o *issuerv1alpha1.VaultTokenRequest, format issuer.Format, path string, secretObj *corev1.Secret) error {
+	roleFormat, ok := format.(issuer.RoleFormat)
+	if !ok {
+		return errors.Errorf("%s output format doesn't support %s auth mode", o.Spec.OutputFormat, issuerv1alpha1.AuthModeKubernetesRole)
+	}
+
+	prev := tokenAccessor(secretObj)
+	serviceAccount := o.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+
+	role := vault.KubernetesRole{
+		AuthPath:       vault.KubernetesAuthPath(r.Issuer.KubernetesAuthPath),
+		Name:           policyName(o),
+		ServiceAccount: serviceAccount,
+		Namespace:      o.Namespace,
+		Policy:         policyName(o),
+		Options:        r.tokenOptions(o),
+	}
+	err := vault.WriteKubernetesRole(cl, role)
+	if err != nil {
+		return err
+	}
+
+	err = r.writeSecret(o, secretObj, roleFormat.RoleSecretData(role, rootVaultConf, path), "")
+	if err != nil {
+		return err
+	}
+
+	if prev != "" {
+		err = vault.RevokeAccessor(cl, prev, policyName(o))
+		if err != nil {
+			r.Log.Error(err, "can't revoke replaced vault token", "namespace", o.Namespace, "name", o.Name)
+		}
+	}
+
+	return nil
+}
+
+// renewToken renews the token once it has less than half of its TTL left.
+// It returns false if the token has to be re-issued.
+func (r *VaultTokenRequestReconciler) renewToken(cl *api.Client, o *issuerv1alpha1.VaultTokenRequest, format issuer.Format, secretObj *corev1.Secret, accessor string) (time.Duration, bool, error) {
+	sec, err := cl.Auth().Token().LookupAccessor(accessor)
+	if vault.IsInvalidAccessor(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrap(err, "lookup accessor")
+	}
+
+	ttl, err := sec.TokenTTL()
+	if err != nil {
+		return 0, false, errors.Wrap(err, "get token ttl")
+	}
+	if ttl == 0 {
+		// the token never expires
+		return r.updateTokenStatus(o, accessor, 0), true, nil
+	}
+
+	creationTTL, err := vault.SecondsFrom(sec.Data["creation_ttl"])
+	if err != nil {
+		return 0, false, errors.Wrap(err, "get token creation ttl")
+	}
+	if ttl > creationTTL/2 {
+		return r.updateTokenStatus(o, accessor, ttl), true, nil
+	}
+
+	token, err := format.Token(secretObj.Data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	renewed, err := cl.Auth().Token().Renew(token, 0)
+	if err != nil {
+		return 0, false, nil
+	}
+
+	lease := time.Duration(renewed.Auth.LeaseDuration) * time.Second
+	if lease <= ttl {
+		// the token reached its max ttl
+		return 0, false, nil
+	}
+
+	return r.updateTokenStatus(o, accessor, lease), true, nil
+}
+
+// updateTokenStatus sets the token fields of the status and returns when
+// the token should be renewed.
+func (r *VaultTokenRequestReconciler) updateTokenStatus(o *issuerv1alpha1.VaultTokenRequest, accessor string, ttl time.Duration) time.Duration {
+	o.Status.TokenAccessor = accessor
+	if ttl == 0 {
+		o.Status.ExpirationTime = nil
+		return resyncInterval
+	}
+
+	expires := metav1.NewTime(time.Now().Add(ttl).Truncate(time.Second))
+	o.Status.ExpirationTime = &expires
+
+	if ttl/2 < resyncInterval {
+		return ttl / 2
+	}
+	return resyncInterval
+}
+
+// writeSecret creates the Secret or updates secretObj, the Secret the
+// decision to issue was based on. The update fails if the Secret has been
+// changed since, so nothing is issued twice for a stale Secret. The accessor
+// is empty for roles.
+func (r *VaultTokenRequestReconciler) writeSecret(o *issuerv1alpha1.VaultTokenRequest, secretObj *corev1.Secret, data map[string][]byte, accessor string) error {
+	create := secretObj == nil
+	if create {
+		secretObj = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.Spec.SecretName,
+				Namespace: o.Namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+	} else {
+		secretObj = secretObj.DeepCopy()
+	}
+
+	secretObj.Data = data
+	if secretObj.Annotations == nil {
+		secretObj.Annotations = make(map[string]string)
+	}
+	secretObj.Annotations[requestGenerationAnnotation] = strconv.FormatInt(o.Generation, 10)
+	if accessor != "" {
+		secretObj.Annotations[issuer.TokenAccessorAnnotation] = accessor
+		secretObj.Annotations[issuer.TokenIssuedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	} else {
+		delete(secretObj.Annotations, issuer.TokenAccessorAnnotation)
+		delete(secretObj.Annotations, issuer.TokenIssuedAtAnnotation)
+	}
+
+	err := controllerutil.SetControllerReference(o, secretObj, r.Scheme)
+	if err != nil {
+		return errors.Wrap(err, "set controller reference")
+	}
+
+	if create {
+		err = r.Client.Create(context.TODO(), secretObj)
+	} else {
+		err = r.Client.Update(context.TODO(), secretObj)
+	}
+	if err != nil {
+		return errors.Wrap(err, "write secret")
+	}
+
+	return nil
+}
+
+// tokenAccessor returns the accessor of the token written to the Secret.
+func tokenAccessor(secretObj *corev1.Secret) string {
+	if secretObj == nil {
+		return ""
+	}
+	return secretObj.Annotations[issuer.TokenAccessorAnnotation]
+}
+
+// revoke removes everything issued for the request from Vault. The Secret
+// is garbage collected by Kubernetes.
+func (r *VaultTokenRequestReconciler) revoke(o *issuerv1alpha1.VaultTokenRequest) error {
+	cl, _, err := r.Issuer.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	// the status is only a fallback for a Secret deleted by hand, it may be
+	// older than the Secret
+	accessor := o.Status.TokenAccessor
+	if o.Spec.SecretName != "" {
+		secretObj := &corev1.Secret{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: o.Namespace, Name: o.Spec.SecretName}, secretObj)
+		switch {
+		case err == nil && metav1.IsControlledBy(secretObj, o):
+			accessor = tokenAccessor(secretObj)
+		case err != nil && !apierrors.IsNotFound(err):
+			return errors.Wrap(err, "get secret")
+		}
+	}
+
+	if accessor != "" {
+		err = vault.RevokeAccessor(cl, accessor, policyName(o))
+		if err != nil {
+			return errors.Wrap(err, "revoke token")
+		}
+	}
+
+	if authMode(o) == issuerv1alpha1.AuthModeKubernetesRole {
+		err = vault.DeleteKubernetesRole(cl, vault.KubernetesAuthPath(r.Issuer.KubernetesAuthPath), policyName(o))
+		if err != nil {
+			return errors.Wrap(err, "delete role")
+		}
+	}
+
+	err = cl.Sys().DeletePolicy(policyName(o))
+	if err != nil {
+		return errors.Wrap(err, "delete policy")
+	}
+
+	return nil
+}
+
+func (r *VaultTokenRequestReconciler) tokenOptions(o *issuerv1alpha1.VaultTokenRequest) vault.TokenOptions {
+	opts := r.Issuer.TokenOptions
+	if o.Spec.TTL != nil {
+		opts.TTL = o.Spec.TTL.Duration
+	}
+	if o.Spec.MaxTTL != nil {
+		opts.MaxTTL = o.Spec.MaxTTL.Duration
+	}
+	if o.Spec.Period != nil {
+		opts.Period = o.Spec.Period.Duration
+	}
+	return opts
+}
+
+func authMode(o *issuerv1alpha1.VaultTokenRequest) issuerv1alpha1.AuthMode {
+	if o.Spec.AuthMode == "" {
+		return issuerv1alpha1.AuthModeToken
+	}
+	return o.Spec.AuthMode
+}
+
+// policyName is the name of the request's policy and role. Names of
+// Kubernetes objects can't contain "_", so it can't clash with policies of
+// clusters issued by annotation, and namespaces can't contain ".", so it
+// can't clash with policies of other requests.
+func policyName(o *issuerv1alpha1.VaultTokenRequest) string {
+	return fmt.Sprintf("vtr_%s.%s", o.Namespace, o.Name)
+}
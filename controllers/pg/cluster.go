@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	pgv2 "github.com/Percona-Lab/k8s-vault-issuer/apis/pg/v2"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+)
+
+// vaultSecretNameAnnotation names the secret issued for the cluster, the
+// PostgreSQL cluster spec has no field for it. <cluster>-vault if not set.
+const vaultSecretNameAnnotation = "percona.com/vault-secret-name"
+
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters/status,verbs=get;update;patch
+
+// +kubebuilder:webhook:path=/validate-pgv2-percona-com-v2-perconapgcluster,mutating=false,failurePolicy=ignore,groups=pgv2.percona.com,resources=perconapgclusters,verbs=create;update,versions=v2,name=vperconapgcluster.issuer.percona.com
+
+// cluster adapts PerconaPGCluster to the issuer core, the clusters are
+// reconciled by issuer.Reconciler.
+type cluster struct {
+	*pgv2.PerconaPGCluster
+}
+
+// NewCluster returns an empty PerconaPGCluster cluster.
+func NewCluster() issuer.Cluster {
+	return cluster{&pgv2.PerconaPGCluster{}}
+}
+
+func (c cluster) Object() controllerutil.Object {
+	return c.PerconaPGCluster
+}
+
+func (c cluster) SecretName() string {
+	if name, ok := c.Annotations[vaultSecretNameAnnotation]; ok && name != "" {
+		return name
+	}
+	return c.Name + "-vault"
+}
+
+func (c cluster) SecretNameField() string {
+	return "the " + vaultSecretNameAnnotation + " annotation"
+}
+
+func (c cluster) Format() issuer.Format {
+	return issuer.PlainFormat{}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	psmdbv1 "github.com/Percona-Lab/k8s-vault-issuer/apis/psmdb/v1"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+)
+
+// +kubebuilder:rbac:groups=psmdb.percona.com,resources=perconaservermongodbs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=psmdb.percona.com,resources=perconaservermongodbs/status,verbs=get;update;patch
+
+// +kubebuilder:webhook:path=/validate-psmdb-percona-com-v1-perconaservermongodb,mutating=false,failurePolicy=ignore,groups=psmdb.percona.com,resources=perconaservermongodbs,verbs=create;update,versions=v1,name=vperconaservermongodb.issuer.percona.com
+
+// cluster adapts PerconaServerMongoDB to the issuer core, the clusters are
+// reconciled by issuer.Reconciler.
+type cluster struct {
+	*psmdbv1.PerconaServerMongoDB
+}
+
+// NewCluster returns an empty PerconaServerMongoDB cluster.
+func NewCluster() issuer.Cluster {
+	return cluster{&psmdbv1.PerconaServerMongoDB{}}
+}
+
+func (c cluster) Object() controllerutil.Object {
+	return c.PerconaServerMongoDB
+}
+
+// SecretName is spec.secrets.vault.
+func (c cluster) SecretName() string {
+	if c.Spec.Secrets == nil {
+		return ""
+	}
+	return c.Spec.Secrets.Vault
+}
+
+func (c cluster) SecretNameField() string {
+	return "spec.secrets.vault"
+}
+
+func (c cluster) Format() issuer.Format {
+	return issuer.MongoDBFormat{}
+}
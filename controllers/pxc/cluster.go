@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	pxcv1 "github.com/Percona-Lab/k8s-vault-issuer/apis/pxc/v1"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+)
+
+// +kubebuilder:rbac:groups=pxc.percona.com,resources=perconaxtradbclusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pxc.percona.com,resources=perconaxtradbclusters/status,verbs=get;update;patch
+
+// +kubebuilder:webhook:path=/validate-pxc-percona-com-v1-perconaxtradbcluster,mutating=false,failurePolicy=ignore,groups=pxc.percona.com,resources=perconaxtradbclusters,verbs=create;update,versions=v1,name=vperconaxtradbcluster.issuer.percona.com
+
+// cluster adapts PerconaXtraDBCluster to the issuer core, the clusters are
+// reconciled by issuer.Reconciler.
+type cluster struct {
+	*pxcv1.PerconaXtraDBCluster
+}
+
+// NewCluster returns an empty PerconaXtraDBCluster cluster.
+func NewCluster() issuer.Cluster {
+	return cluster{&pxcv1.PerconaXtraDBCluster{}}
+}
+
+func (c cluster) Object() controllerutil.Object {
+	return c.PerconaXtraDBCluster
+}
+
+func (c cluster) SecretName() string {
+	return c.Spec.VaultSecretName
+}
+
+func (c cluster) SecretNameField() string {
+	return "spec.vaultSecretName"
+}
+
+func (c cluster) Format() issuer.Format {
+	return issuer.KeyringVaultFormat{}
+}
+
+// +kubebuilder:rbac:groups=pxc.percona.com,resources=perconaxtradbclusterbackups,verbs=get;list;watch
+
+func (c cluster) BackupUIDs(cl client.Client, sel issuer.BackupSelector) ([]string, error) {
+	list := &pxcv1.PerconaXtraDBClusterBackupList{}
+	err := cl.List(context.TODO(), list, client.InNamespace(c.Namespace))
+	if err != nil {
+		return nil, errors.Wrap(err, "list backups")
+	}
+
+	names := make(map[string]bool, len(sel.Names))
+	for _, name := range sel.Names {
+		names[name] = false
+	}
+
+	uids := []string{}
+	for _, b := range list.Items {
+		if b.Spec.PXCCluster != c.Name {
+			continue
+		}
+		if _, ok := names[b.Name]; len(names) > 0 && !ok {
+			continue
+		}
+		if !sel.Created(b.CreationTimestamp.Time) {
+			continue
+		}
+		names[b.Name] = true
+		uids = append(uids, string(b.UID))
+	}
+
+	for name, found := range names {
+		if !found {
+			return nil, errors.Errorf("backup %s of cluster %s not found", name, c.Name)
+		}
+	}
+
+	return uids, nil
+}
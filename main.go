@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -29,10 +30,19 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	issuerv1alpha1 "github.com/Percona-Lab/k8s-vault-issuer/apis/issuer/v1alpha1"
+	pgv2 "github.com/Percona-Lab/k8s-vault-issuer/apis/pg/v2"
 	psmdbv1 "github.com/Percona-Lab/k8s-vault-issuer/apis/psmdb/v1"
 	pxcv1 "github.com/Percona-Lab/k8s-vault-issuer/apis/pxc/v1"
+	issuercontroller "github.com/Percona-Lab/k8s-vault-issuer/controllers/issuer"
+	pgcontroller "github.com/Percona-Lab/k8s-vault-issuer/controllers/pg"
+	psmdbcontroller "github.com/Percona-Lab/k8s-vault-issuer/controllers/psmdb"
 	pxccontroller "github.com/Percona-Lab/k8s-vault-issuer/controllers/pxc"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/issuer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -46,16 +56,70 @@ func init() {
 
 	utilruntime.Must(pxcv1.AddToScheme(scheme))
 	utilruntime.Must(psmdbv1.AddToScheme(scheme))
+	utilruntime.Must(pgv2.AddToScheme(scheme))
+	utilruntime.Must(issuerv1alpha1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var vaultDataRetention string
+	var vaultAuthMethod string
+	var issueMode string
+	var issueKubernetesAuthPath string
+	var k8sAuth vault.KubernetesAuth
+	var tokenOpts vault.TokenOptions
+	var tokenRenewInterval time.Duration
+	var tokenRotationInterval time.Duration
+	var tokenRotationGracePeriod time.Duration
+	var transferConcurrency int
+	var transferBatchSize int
+	var enableWebhook bool
+	var enablePSMDB bool
+	var enablePG bool
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&vaultDataRetention, "vault-data-retention", issuer.DataRetain,
+		"What to do with the cluster's data in Vault when the cluster is deleted: "+
+			issuer.DataRetain+" or "+issuer.DataDelete+".")
+	flag.StringVar(&vaultAuthMethod, "vault-auth-method", "token",
+		"How the issuer logs into Vault: token (static token or AppRole from the root secret) or kubernetes.")
+	flag.StringVar(&k8sAuth.MountPath, "vault-kubernetes-auth-path", "kubernetes",
+		"Mount path of the Vault Kubernetes auth method.")
+	flag.StringVar(&k8sAuth.Role, "vault-kubernetes-auth-role", "",
+		"Vault Kubernetes auth role the issuer logs in with.")
+	flag.StringVar(&issueMode, "vault-issue-mode", issuer.IssueModeToken,
+		"What is issued for clusters: "+issuer.IssueModeToken+" or "+issuer.IssueModeKubernetesRole+". "+
+			"Can be overridden by the percona.com/vault-issue-mode cluster annotation.")
+	flag.StringVar(&issueKubernetesAuthPath, "vault-issue-kubernetes-auth-path", "kubernetes",
+		"Mount path of the Vault Kubernetes auth method roles are issued in.")
+	flag.DurationVar(&tokenOpts.TTL, "vault-token-ttl", 0,
+		"TTL of issued Vault tokens. Can be overridden by the percona.com/vault-token-ttl cluster annotation.")
+	flag.DurationVar(&tokenOpts.MaxTTL, "vault-token-max-ttl", 0,
+		"Explicit max TTL of issued Vault tokens. Can be overridden by the percona.com/vault-token-max-ttl cluster annotation.")
+	flag.DurationVar(&tokenOpts.Period, "vault-token-period", 0,
+		"Period of issued Vault tokens. Can be overridden by the percona.com/vault-token-period cluster annotation.")
+	flag.DurationVar(&tokenRenewInterval, "vault-token-renew-interval", time.Minute,
+		"How often issued Vault tokens are checked for renewal.")
+	flag.DurationVar(&tokenRotationInterval, "vault-token-rotation-interval", 0,
+		"How often issued Vault tokens are rotated, 0 disables scheduled rotation. "+
+			"Can be overridden by the percona.com/vault-token-rotation-interval cluster annotation.")
+	flag.DurationVar(&tokenRotationGracePeriod, "vault-token-rotation-grace-period", 5*time.Minute,
+		"How long a rotated Vault token stays valid before it is revoked.")
+	flag.IntVar(&transferConcurrency, "vault-transfer-concurrency", transfer.DefaultConcurrency,
+		"How many keys are copied at once by a key transfer.")
+	flag.IntVar(&transferBatchSize, "vault-transfer-batch-size", transfer.DefaultBatchSize,
+		"How many keys a key transfer copies between progress reports.")
+	flag.BoolVar(&enablePSMDB, "enable-psmdb", false,
+		"Issue Vault tokens for PerconaServerMongoDB clusters, the psmdb.percona.com CRDs must be installed.")
+	flag.BoolVar(&enablePG, "enable-pg", false,
+		"Issue Vault tokens for PerconaPGCluster clusters, the pgv2.percona.com CRDs must be installed.")
+	flag.BoolVar(&enableWebhook, "enable-webhook", false,
+		"Serve the validating webhook of cluster annotations on port 9443. "+
+			"Requires a serving certificate in /tmp/k8s-webhook-server/serving-certs.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -72,6 +136,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	if vaultDataRetention != issuer.DataRetain && vaultDataRetention != issuer.DataDelete {
+		setupLog.Error(errors.Errorf("unknown value %q", vaultDataRetention), "invalid --vault-data-retention flag")
+		os.Exit(1)
+	}
+
+	if issueMode != issuer.IssueModeToken && issueMode != issuer.IssueModeKubernetesRole {
+		setupLog.Error(errors.Errorf("unknown value %q", issueMode), "invalid --vault-issue-mode flag")
+		os.Exit(1)
+	}
+
+	var vaultAuth vault.Authenticator
+	switch vaultAuthMethod {
+	case "token":
+		vaultAuth = &vault.RootConfAuth{}
+	case "kubernetes":
+		vaultAuth = &k8sAuth
+	default:
+		setupLog.Error(errors.Errorf("unknown value %q", vaultAuthMethod), "invalid --vault-auth-method flag")
+		os.Exit(1)
+	}
+
 	rootSecretName, ok := os.LookupEnv("VAULT_SECRET_NAME")
 	if !ok {
 		setupLog.Error(errors.New("VAULT_SECRET_NAME env variable is not set"), "can't find vault secret name")
@@ -84,24 +169,80 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&pxccontroller.PerconaXtraDBClusterReconciler{
-		Client:              mgr.GetClient(),
-		Log:                 ctrl.Log.WithName("controllers").WithName("PerconaXtraDBCluster"),
-		Scheme:              mgr.GetScheme(),
-		Namespace:           operatorNS,
-		RootVaultSecretName: rootSecretName,
-	}).SetupWithManager(mgr); err != nil {
+	clusterIssuer := &issuer.Issuer{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("issuer"),
+		Root: vault.Root{
+			Client:     mgr.GetClient(),
+			Namespace:  operatorNS,
+			SecretName: rootSecretName,
+			Auth:       vaultAuth,
+		},
+		DataRetention:            vaultDataRetention,
+		TokenOptions:             tokenOpts,
+		IssueMode:                issueMode,
+		KubernetesAuthPath:       issueKubernetesAuthPath,
+		TokenRotationInterval:    tokenRotationInterval,
+		TokenRotationGracePeriod: tokenRotationGracePeriod,
+		TransferConcurrency:      transferConcurrency,
+		TransferBatchSize:        transferBatchSize,
+	}
+
+	if err = clusterReconciler(mgr, clusterIssuer, "PerconaXtraDBCluster", pxccontroller.NewCluster, tokenRenewInterval).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PerconaXtraDBCluster")
 		os.Exit(1)
 	}
-	// if err = (&psmdbcontroller.PerconaServerMongoDBReconciler{
-	// 	Client: mgr.GetClient(),
-	// 	Log:    ctrl.Log.WithName("controllers").WithName("PerconaServerMongoDB"),
-	// 	Scheme: mgr.GetScheme(),
-	// }).SetupWithManager(mgr); err != nil {
-	// 	setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMongoDB")
-	// 	os.Exit(1)
-	// }
+	if err = (&issuercontroller.VaultTokenRequestReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("VaultTokenRequest"),
+		Scheme: mgr.GetScheme(),
+		Issuer: clusterIssuer,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VaultTokenRequest")
+		os.Exit(1)
+	}
+
+	// watches of CRDs that aren't installed fail the manager's start, so
+	// clusters of other operators are enabled by flags
+	transferClusters := map[issuerv1alpha1.ClusterKind]func() issuer.Cluster{
+		issuerv1alpha1.ClusterKindPerconaXtraDBCluster: pxccontroller.NewCluster,
+	}
+	webhooks := map[string]func() issuer.Cluster{
+		"/validate-pxc-percona-com-v1-perconaxtradbcluster": pxccontroller.NewCluster,
+	}
+	if enablePSMDB {
+		if err = clusterReconciler(mgr, clusterIssuer, "PerconaServerMongoDB", psmdbcontroller.NewCluster, tokenRenewInterval).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMongoDB")
+			os.Exit(1)
+		}
+		transferClusters[issuerv1alpha1.ClusterKindPerconaServerMongoDB] = psmdbcontroller.NewCluster
+		webhooks["/validate-psmdb-percona-com-v1-perconaservermongodb"] = psmdbcontroller.NewCluster
+	}
+	if enablePG {
+		if err = clusterReconciler(mgr, clusterIssuer, "PerconaPGCluster", pgcontroller.NewCluster, tokenRenewInterval).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PerconaPGCluster")
+			os.Exit(1)
+		}
+		webhooks["/validate-pgv2-percona-com-v2-perconapgcluster"] = pgcontroller.NewCluster
+	}
+	if err = (&issuercontroller.VaultKeyTransferReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("VaultKeyTransfer"),
+		Scheme:   mgr.GetScheme(),
+		Issuer:   clusterIssuer,
+		Clusters: transferClusters,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VaultKeyTransfer")
+		os.Exit(1)
+	}
+	if enableWebhook {
+		for path, newCluster := range webhooks {
+			mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: &issuer.Validator{
+				Client:     mgr.GetClient(),
+				NewCluster: newCluster,
+			}})
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -111,6 +252,24 @@ func main() {
 	}
 }
 
+// clusterReconciler returns the reconciler of clusters of the kind with
+// a token renewer.
+func clusterReconciler(mgr ctrl.Manager, clusterIssuer *issuer.Issuer, kind string, newCluster func() issuer.Cluster, renewInterval time.Duration) *issuer.Reconciler {
+	return &issuer.Reconciler{
+		Issuer:     clusterIssuer,
+		Log:        ctrl.Log.WithName("controllers").WithName(kind),
+		Scheme:     mgr.GetScheme(),
+		Recorder:   mgr.GetEventRecorderFor("vault-issuer"),
+		NewCluster: newCluster,
+		Renewer: &issuer.TokenRenewer{
+			Issuer:     clusterIssuer,
+			NewCluster: newCluster,
+			Interval:   renewInterval,
+			Log:        ctrl.Log.WithName("renewer").WithName(kind),
+		},
+	}
+}
+
 func operatorNamespace() (string, error) {
 	nsBytes, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
 	if err != nil {
@@ -0,0 +1,80 @@
+package issuer
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+)
+
+// checkApproval fails if the approval of src is expired or used up, its
+// annotations are removed then unless it is a dry run.
+func (i *Issuer) checkApproval(src Cluster, dryRun bool) (transfer.Approval, error) {
+	a, err := transfer.ParseApproval(src.Object().GetAnnotations())
+	if err != nil {
+		return a, errors.Wrapf(err, "source cluster %s", namespacedName(src))
+	}
+
+	err = a.Check(time.Now())
+	if err != nil && !dryRun {
+		rerr := RemoveAnnotations(i.Client, src.Object(), transfer.ApprovalAnnotations...)
+		if rerr != nil {
+			i.Log.Error(rerr, "can't remove approval", "cluster", namespacedName(src))
+		}
+	}
+	if err != nil {
+		return a, errors.Wrapf(err, "source cluster %s", namespacedName(src))
+	}
+
+	return a, nil
+}
+
+// useApproval takes a use of the approval of src if its uses are limited,
+// the last use removes the approval. The use is taken before keys are
+// copied, so concurrent transfers can't exceed the limit, and is given back
+// with the returned function if the transfer fails.
+func (i *Issuer) useApproval(src Cluster, a transfer.Approval) (func(), error) {
+	if a.Uses < 0 {
+		return func() {}, nil
+	}
+
+	o := src.Object()
+	uses := strconv.Itoa(a.Uses)
+	left := strconv.Itoa(a.Uses - 1)
+	if a.Uses == 1 {
+		left = ""
+	}
+
+	approval := make(map[string]string)
+	others := make([]string, 0)
+	for _, k := range transfer.ApprovalAnnotations {
+		v, ok := o.GetAnnotations()[k]
+		if !ok {
+			continue
+		}
+		approval[k] = v
+		if k != transfer.AllowUsesAnnotation {
+			others = append(others, k)
+		}
+	}
+
+	err := SwapAnnotation(i.Client, o, transfer.AllowUsesAnnotation, uses, left, others...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "use approval of %s, it may be used concurrently", namespacedName(src))
+	}
+	i.Log.Info("used transfer approval", "cluster", namespacedName(src), "uses left", a.Uses-1)
+
+	return func() {
+		var err error
+		if left == "" {
+			err = SetAnnotations(i.Client, o, approval)
+		} else {
+			err = SwapAnnotation(i.Client, o, transfer.AllowUsesAnnotation, left, uses)
+		}
+		if err != nil {
+			i.Log.Error(err, "can't give back approval use", "cluster", namespacedName(src))
+		}
+	}, nil
+}
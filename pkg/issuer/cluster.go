@@ -0,0 +1,102 @@
+package issuer
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// Cluster is a database cluster that needs a Vault secret. Every supported
+// CRD plugs in with an adapter wrapping its object.
+type Cluster interface {
+	// Object is the wrapped cluster object. Its namespace, name, annotations
+	// and finalizers are used, it is patched to change the latter two.
+	Object() controllerutil.Object
+	// SecretName is the name of the secret issued for the cluster, empty if
+	// the cluster doesn't have one configured.
+	SecretName() string
+	// SecretNameField is where the secret name is set, e.g.
+	// spec.vaultSecretName, it is shown to users in messages.
+	SecretNameField() string
+	// Format defines where the cluster keeps its data in Vault and the
+	// layout of the issued secret.
+	Format() Format
+}
+
+// Format of the data of a database in Vault and of its issued secret.
+// Paths are logical paths of the secret, see vault.KV.
+type Format interface {
+	// Policy grants access to the cluster's path.
+	Policy(kv vault.KV, path string) (string, error)
+	// SecretData is the content of the issued secret.
+	SecretData(token string, rootConf vault.Conf, kv vault.KV, path string) (map[string][]byte, error)
+	// Token returns the token written to the issued secret's data.
+	Token(data map[string][]byte) (string, error)
+	// ReadConf reads the Vault configuration from the issued secret, its
+	// SecretMountPoint is the logical path.
+	ReadConf(c client.Client, namespace, secretName string) (vault.Conf, error)
+	// TransferKeys copies keys required to restore backups between
+	// the paths of two clusters.
+	TransferKeys(from, to transfer.Location, opts transfer.Options) ([]transfer.KeyResult, error)
+}
+
+// RoleFormat is implemented by formats whose readers can log into Vault
+// themselves, they can be issued a role in IssueModeKubernetesRole.
+type RoleFormat interface {
+	// RoleSecretData is the content of the secret issued in
+	// IssueModeKubernetesRole.
+	RoleSecretData(role vault.KubernetesRole, rootConf vault.Conf, path string) map[string][]byte
+}
+
+func namespacedName(c Cluster) transfer.Cluster {
+	return transfer.Cluster{
+		Name:      c.Object().GetName(),
+		Namespace: c.Object().GetNamespace(),
+	}
+}
+
+// BackupSelector selects backups of a cluster by name and creation time.
+// Zero times don't limit the selection.
+type BackupSelector struct {
+	Names         []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Empty is true if the selector doesn't select anything by itself.
+func (s BackupSelector) Empty() bool {
+	return len(s.Names) == 0 && s.CreatedAfter.IsZero() && s.CreatedBefore.IsZero()
+}
+
+// Created is true if the creation time is within the selected range.
+func (s BackupSelector) Created(t time.Time) bool {
+	if !s.CreatedAfter.IsZero() && t.Before(s.CreatedAfter) {
+		return false
+	}
+	if !s.CreatedBefore.IsZero() && !t.Before(s.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// BackupLister is implemented by clusters whose backups are Kubernetes
+// objects, so keys can be transferred for backups selected by name or time.
+type BackupLister interface {
+	// BackupUIDs returns the UIDs of the cluster's selected backups. It fails
+	// if any of the named backups doesn't exist.
+	BackupUIDs(c client.Client, sel BackupSelector) ([]string, error)
+}
+
+// BackupUIDs resolves the selector against the backups of the cluster.
+func (i *Issuer) BackupUIDs(c Cluster, sel BackupSelector) ([]string, error) {
+	l, ok := c.(BackupLister)
+	if !ok {
+		return nil, errors.Errorf("backups of cluster %s can't be selected by name or creation time", c.Object().GetName())
+	}
+	return l.BackupUIDs(i.Client, sel)
+}
@@ -0,0 +1,435 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// TokenIssuedAtAnnotation holds the time the token was written to the secret.
+const TokenIssuedAtAnnotation = "percona.com/vault-token-issued-at"
+
+// Issuer issues, revokes and transfers Vault secrets of clusters
+// regardless of the database.
+type Issuer struct {
+	Client client.Client
+	Log    logr.Logger
+	// Root is the issuer's own Vault configuration.
+	Root vault.Root
+	// DataRetention defines what happens with the cluster's Vault data
+	// on cluster deletion: DataRetain or DataDelete.
+	DataRetention string
+	// TokenOptions are used for issued tokens unless overridden
+	// by the cluster annotations.
+	TokenOptions vault.TokenOptions
+	// IssueMode is IssueModeToken or IssueModeKubernetesRole, can be
+	// overridden by the cluster annotation.
+	IssueMode string
+	// KubernetesAuthPath is mount path of the Kubernetes auth method
+	// roles are created in with IssueModeKubernetesRole.
+	KubernetesAuthPath string
+	// TokenRotationInterval is how often issued tokens are rotated, zero
+	// disables scheduled rotation.
+	TokenRotationInterval time.Duration
+	// TokenRotationGracePeriod is how long a rotated token stays valid.
+	TokenRotationGracePeriod time.Duration
+	// TransferConcurrency and TransferBatchSize are used for key transfers
+	// unless set in the transfer options.
+	TransferConcurrency int
+	TransferBatchSize   int
+}
+
+// ProcessIssueAnnotation issues a token or role and the secret for the
+// cluster, unless the secret exists already: the annotation is deleted then.
+// It reports whether the secret was issued.
+func (i *Issuer) ProcessIssueAnnotation(c Cluster) (bool, error) {
+	secret, err := i.GetSecret(c)
+	if err != nil {
+		return false, err
+	}
+	if secret != nil {
+		i.Log.Info("issued secret was found, delete annotation", "cluster", namespacedName(c))
+		return false, DeleteAnnotation(i.Client, c.Object(), IssueAnnotation)
+	}
+
+	err = AddFinalizer(i.Client, c.Object(), TokenFinalizer)
+	if err != nil {
+		return false, errors.Wrap(err, "add finalizer")
+	}
+
+	opts, err := TokenOptions(c.Object(), i.TokenOptions)
+	if err != nil {
+		return false, errors.Wrap(err, "get token options")
+	}
+
+	switch mode := i.issueMode(c.Object()); mode {
+	case IssueModeToken:
+		err = i.IssueSecret(c, opts)
+	case IssueModeKubernetesRole:
+		err = i.IssueRole(c, opts)
+	default:
+		err = errors.Errorf("unknown issue mode %q", mode)
+	}
+
+	return err == nil, err
+}
+
+// GetSecret returns the secret issued for the cluster, nil if there is none.
+func (i *Issuer) GetSecret(c Cluster) (*corev1.Secret, error) {
+	if c.SecretName() == "" {
+		return nil, errors.Errorf("vault secret name is not set in %s", c.SecretNameField())
+	}
+
+	secret := &corev1.Secret{}
+	err := i.Client.Get(context.TODO(), client.ObjectKey{Namespace: c.Object().GetNamespace(), Name: c.SecretName()}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get token secret")
+	}
+
+	return secret, nil
+}
+
+// IssueSecret writes the cluster's policy, creates a token bound to it and
+// writes the token to the cluster's secret in the cluster's format.
+func (i *Issuer) IssueSecret(c Cluster, opts vault.TokenOptions) error {
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	ns, secretName := c.Object().GetNamespace(), c.SecretName()
+	path := vault.SecretPath(rootConf.SecretMountPoint, ns, secretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return err
+	}
+	policy, err := c.Format().Policy(kv, path)
+	if err != nil {
+		return err
+	}
+
+	policyName := PolicyName(ns, secretName)
+	sec, err := IssueToken(cl, policyName, policy, opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.Format().SecretData(sec.Auth.ClientToken, rootConf, kv, path)
+	if err == nil {
+		err = i.Client.Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: ns,
+				Annotations: map[string]string{
+					TokenAccessorAnnotation: sec.Auth.Accessor,
+					TokenIssuedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+				},
+			},
+			Data: data,
+			Type: corev1.SecretTypeOpaque,
+		})
+		err = errors.Wrap(err, "create token secret")
+	}
+	if err != nil {
+		if rerr := cl.Auth().Token().RevokeAccessor(sec.Auth.Accessor); rerr != nil {
+			i.Log.Error(rerr, "can't revoke unused vault token", "secret", secretName, "namespace", ns)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Revoke revokes the cluster's token or role and policy and deletes the
+// cluster's Vault data unless it is retained. Nothing is revoked if the
+// secret name is cleared, since the policy can't be found without it.
+func (i *Issuer) Revoke(c Cluster) error {
+	if c.SecretName() == "" {
+		i.Log.Info("vault secret name is not set, nothing to revoke", "cluster", namespacedName(c))
+		return nil
+	}
+
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	secret, err := i.GetSecret(c)
+	if err != nil {
+		return err
+	}
+	accessor := ""
+	if secret != nil {
+		accessor = secret.Annotations[TokenAccessorAnnotation]
+	}
+
+	err = i.deleteRole(cl, c, secret)
+	if err != nil {
+		return err
+	}
+
+	ns, secretName := c.Object().GetNamespace(), c.SecretName()
+	err = RevokeToken(cl, accessor, PolicyName(ns, secretName))
+	if err != nil {
+		return err
+	}
+
+	if i.DataRetention != DataDelete {
+		return nil
+	}
+
+	path := vault.SecretPath(rootConf.SecretMountPoint, ns, secretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return err
+	}
+
+	// metadata of the version 2 engine is deleted with all versions
+	i.Log.Info("deleting vault data", "path", kv.MetadataPath(path))
+	return errors.Wrap(vault.DeleteTree(cl.Logical(), kv.MetadataPath(path)), "delete vault data")
+}
+
+// ProcessTransferAnnotation copies keys of the clusters listed in the
+// annotation, as name.namespace, into the cluster's Vault path, so backups
+// of those clusters can be restored. newCluster returns an empty cluster of
+// the same kind to read source clusters into. The annotation is deleted if
+// all clusters are processed, failed ones are kept in it otherwise and
+// returned as failures. Checksums of the transferred keys are returned as
+// "<source> <key>: <checksum>".
+func (i *Issuer) ProcessTransferAnnotation(dst Cluster, sources string, newCluster func() Cluster) (transferred, failures []string, err error) {
+	dstName := namespacedName(dst)
+
+	opts, err := transferOptions(dst)
+	if err != nil {
+		return nil, []string{err.Error()}, nil
+	}
+
+	failedClusters := make([]string, 0)
+	for _, v := range sourceClusters(sources) {
+		src, err := i.sourceCluster(v, newCluster)
+		if err == nil {
+			var checksums []string
+			checksums, err = i.TransferKeys(src, dst, opts)
+			for _, sum := range checksums {
+				transferred = append(transferred, v+" "+sum)
+			}
+		}
+		if err != nil {
+			i.Log.Error(err, "can't process cluster", "src cluster", v, "cluster", dstName)
+			failedClusters = append(failedClusters, v)
+			failures = append(failures, fmt.Sprintf("%s: %v", v, err))
+		}
+	}
+
+	if len(failedClusters) == 0 {
+		return transferred, nil, DeleteAnnotation(i.Client, dst.Object(), TransferKeysAnnotation)
+	}
+
+	return transferred, failures, ReplaceAnnotation(i.Client, dst.Object(), TransferKeysAnnotation, strings.Join(failedClusters, ","))
+}
+
+// TransferKeys copies keys from src to dst if src allows it. It returns
+// checksums of the copied and moved keys as "<key>: <checksum>", also if
+// some keys failed.
+func (i *Issuer) TransferKeys(src, dst Cluster, opts transfer.Options) ([]string, error) {
+	results, err := i.TransferKeyResults(src, dst, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	checksums := make([]string, 0, len(results))
+	for _, res := range results {
+		if res.Result == transfer.ResultFailed {
+			i.Log.Error(errors.New(res.Message), "can't copy key", "key", res.Key)
+			failed++
+		}
+		if res.Result == transfer.ResultCopied || res.Result == transfer.ResultMoved {
+			i.Log.Info("transferred key", "key", res.Key, "result", res.Result, "checksum", res.Checksum,
+				"from", namespacedName(src), "to", namespacedName(dst))
+			checksums = append(checksums, fmt.Sprintf("%s: %s", res.Key, res.Checksum))
+		}
+	}
+	if failed > 0 {
+		return checksums, errors.Errorf("%d of %d keys are not copied", failed, len(results))
+	}
+
+	return checksums, nil
+}
+
+// TransferKeyResults copies keys from src to dst if src allows it and
+// returns the result of every key.
+func (i *Issuer) TransferKeyResults(src, dst Cluster, opts transfer.Options) ([]transfer.KeyResult, error) {
+	err := i.checkAllowed(src, dst, transfer.AllowAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Move {
+		err = i.checkAllowed(src, dst, transfer.AllowMoveAnnotation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	approval, err := i.checkApproval(src, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	if src.Format() != dst.Format() {
+		return nil, errors.Errorf("can't transfer keys from %T to %T", src.Object(), dst.Object())
+	}
+
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return nil, errors.Wrap(err, "setup vault client")
+	}
+
+	from, err := i.location(cl, rootConf, src)
+	if err != nil {
+		return nil, err
+	}
+	to, err := i.location(cl, rootConf, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Concurrency == 0 {
+		opts.Concurrency = i.TransferConcurrency
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = i.TransferBatchSize
+	}
+	progress := opts.Progress
+	opts.Progress = func(done, total int) {
+		i.Log.Info("transferring keys", "from", from.Path, "to", to.Path, "done", done, "total", total)
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	if opts.DryRun {
+		return dst.Format().TransferKeys(from, to, opts)
+	}
+
+	release, err := i.useApproval(src, approval)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Log.Info("transferring keys", "from", from.Path, "to", to.Path)
+	results, err := dst.Format().TransferKeys(from, to, opts)
+	if !approvalUsed(results, err) {
+		release()
+	}
+
+	return results, err
+}
+
+// approvalUsed reports whether the transfer uses up an approval: it did
+// unless it failed without copying or moving any key, a retry would
+// transfer the keys again otherwise.
+func approvalUsed(results []transfer.KeyResult, err error) bool {
+	failed := err != nil
+	for _, res := range results {
+		if res.Result == transfer.ResultCopied || res.Result == transfer.ResultMoved {
+			return true
+		}
+		failed = failed || res.Result == transfer.ResultFailed
+	}
+	return !failed
+}
+
+// checkAllowed fails unless the allowlist in the annotation of src
+// allows dst.
+func (i *Issuer) checkAllowed(src, dst Cluster, annotation string) error {
+	v, ok := src.Object().GetAnnotations()[annotation]
+	if !ok {
+		return errors.Errorf("source cluster %s has no %s annotation", namespacedName(src), annotation)
+	}
+	al, err := transfer.ParseAllowlist(v)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s annotation of %s", annotation, namespacedName(src))
+	}
+
+	req := transfer.Requester{Cluster: namespacedName(dst), Labels: dst.Object().GetLabels()}
+	if al.NamespaceSelected() {
+		ns := &corev1.Namespace{}
+		err = i.Client.Get(context.TODO(), client.ObjectKey{Name: req.Namespace}, ns)
+		if err != nil {
+			return errors.Wrapf(err, "get namespace %s", req.Namespace)
+		}
+		req.NamespaceLabels = ns.Labels
+	}
+
+	if !al.Allows(req) {
+		return errors.Errorf("source cluster %s doesn't allow %s in the %s annotation", namespacedName(src), namespacedName(dst), annotation)
+	}
+
+	return nil
+}
+
+// location returns where the cluster's secret keeps its data. Clusters on
+// another Vault server than the issuer's one are accessed with their own
+// token, e.g. to migrate keys from a legacy Vault. The secret is editable by
+// the tenant, so on the issuer's server only the path issued for the cluster
+// is accessed with the issuer's token.
+func (i *Issuer) location(cl *api.Client, rootConf vault.Conf, c Cluster) (transfer.Location, error) {
+	conf, err := i.readConf(c)
+	if err != nil {
+		return transfer.Location{}, err
+	}
+
+	if vault.SameServer(conf.URL, rootConf.URL) {
+		issued := vault.SecretPath(rootConf.SecretMountPoint, c.Object().GetNamespace(), c.SecretName())
+		if path.Clean("/"+conf.SecretMountPoint) != path.Clean("/"+issued) {
+			return transfer.Location{}, errors.Errorf("cluster %s secret points to %s instead of the issued path %s",
+				namespacedName(c), conf.SecretMountPoint, issued)
+		}
+	} else {
+		if conf.Token == "" {
+			return transfer.Location{}, errors.Errorf("cluster %s uses vault %s and its secret has no token", namespacedName(c), conf.URL)
+		}
+
+		i.Log.Info("using the cluster's own vault client", "cluster", namespacedName(c), "vault", conf.URL)
+		cl, err = vault.NewClient(conf)
+		if err != nil {
+			return transfer.Location{}, errors.Wrapf(err, "create vault client for %s", conf.URL)
+		}
+	}
+
+	kv, err := vault.DetectKV(cl, conf.SecretMountPoint)
+	if err != nil {
+		return transfer.Location{}, err
+	}
+
+	return transfer.Location{Client: cl, KV: kv, Path: conf.SecretMountPoint}, nil
+}
+
+func (i *Issuer) readConf(c Cluster) (vault.Conf, error) {
+	ns, secretName := c.Object().GetNamespace(), c.SecretName()
+	if secretName == "" {
+		return vault.Conf{}, errors.Errorf("cluster %s has no vault secret name", namespacedName(c))
+	}
+
+	conf, err := c.Format().ReadConf(i.Client, ns, secretName)
+	if err != nil {
+		return conf, errors.Wrapf(err, "get vault conf from namespace: %s, secretName: %s", ns, secretName)
+	}
+
+	return conf, nil
+}
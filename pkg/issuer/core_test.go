@@ -0,0 +1,56 @@
+package issuer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+)
+
+func TestApprovalUsed(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []transfer.KeyResult
+		err     error
+		want    bool
+	}{
+		{
+			name:    "all copied",
+			results: []transfer.KeyResult{{Key: "a", Result: transfer.ResultCopied}, {Key: "b", Result: transfer.ResultCopied}},
+			want:    true,
+		},
+		{
+			name:    "one failed and one copied",
+			results: []transfer.KeyResult{{Key: "a", Result: transfer.ResultFailed}, {Key: "b", Result: transfer.ResultCopied}},
+			want:    true,
+		},
+		{
+			name:    "one failed and one moved",
+			results: []transfer.KeyResult{{Key: "a", Result: transfer.ResultMoved}, {Key: "b", Result: transfer.ResultFailed}},
+			want:    true,
+		},
+		{
+			name:    "failed and skipped",
+			results: []transfer.KeyResult{{Key: "a", Result: transfer.ResultFailed}, {Key: "b", Result: transfer.ResultSkipped}},
+			want:    false,
+		},
+		{
+			name:    "all skipped",
+			results: []transfer.KeyResult{{Key: "a", Result: transfer.ResultSkipped}},
+			want:    true,
+		},
+		{
+			name: "transfer error",
+			err:  errors.New("no transition keys found"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approvalUsed(tt.results, tt.err); got != tt.want {
+				t.Errorf("approvalUsed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
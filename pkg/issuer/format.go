@@ -0,0 +1,235 @@
+package issuer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/keyringconf"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// KeyringVaultFormat is used by the keyring_vault plugin of Percona XtraDB
+// Cluster: keyring_vault.conf and ca.cert. Backups keep their transition
+// keys under the backup/ path.
+type KeyringVaultFormat struct{}
+
+func (KeyringVaultFormat) Policy(kv vault.KV, path string) (string, error) {
+	return KeyringVaultPolicy(kv, path), nil
+}
+
+func (KeyringVaultFormat) SecretData(token string, rootConf vault.Conf, kv vault.KV, path string) (map[string][]byte, error) {
+	data := map[string][]byte{
+		"keyring_vault.conf": KeyringVaultConf(token, rootConf, kv, path),
+	}
+	if rootConf.Cert != nil {
+		data["ca.cert"] = rootConf.Cert
+	}
+
+	return data, nil
+}
+
+func (KeyringVaultFormat) Token(data map[string][]byte) (string, error) {
+	conf, err := keyringconf.Parse(data["keyring_vault.conf"])
+	if err != nil {
+		return "", errors.Wrap(err, "parse keyring_vault.conf")
+	}
+	token, ok := conf.Get(keyringconf.KeyToken)
+	if !ok {
+		return "", errors.New("can't find vault token in secret")
+	}
+	return token, nil
+}
+
+// RoleSecretData describes the role keyring_vault logs in with.
+func (KeyringVaultFormat) RoleSecretData(role vault.KubernetesRole, rootConf vault.Conf, path string) map[string][]byte {
+	data := map[string][]byte{
+		"vault_url":          []byte(rootConf.URL),
+		"vault_auth_path":    []byte(role.AuthPath),
+		"vault_role":         []byte(role.Name),
+		"secret_mount_point": []byte(path),
+	}
+	if rootConf.Cert != nil {
+		data["ca.cert"] = rootConf.Cert
+	}
+
+	return data
+}
+
+func (KeyringVaultFormat) ReadConf(c client.Client, namespace, secretName string) (vault.Conf, error) {
+	return vault.ConfFrom(c, namespace, secretName)
+}
+
+func (KeyringVaultFormat) TransferKeys(from, to transfer.Location, opts transfer.Options) ([]transfer.KeyResult, error) {
+	return transfer.TransitionKeys(from, to, opts)
+}
+
+// KeyringVaultPolicy grants access to the path. keyring_vault finds out the
+// version of the engine itself, it needs to read the mount for that.
+func KeyringVaultPolicy(kv vault.KV, path string) string {
+	policy := kv.Policy(path)
+	if kv.Version == 2 {
+		policy += fmt.Sprintf(`
+path "sys/mounts/%s"
+{
+  capabilities = ["read"]
+}
+`, kv.Mount)
+	}
+	return policy
+}
+
+// KeyringVaultConf returns keyring_vault.conf with the token for the path.
+func KeyringVaultConf(token string, rootConf vault.Conf, kv vault.KV, path string) []byte {
+	conf := keyringconf.New()
+	conf.Set(keyringconf.KeyToken, token)
+	conf.Set(keyringconf.KeyVaultURL, rootConf.URL)
+	conf.Set(keyringconf.KeySecretMountPoint, path)
+	if kv.Version == 2 {
+		conf.Set(keyringconf.KeySecretMountPointVersion, "AUTO")
+	}
+	if rootConf.Cert != nil {
+		conf.Set(keyringconf.KeyVaultCA, keyringconf.DefaultCAPath)
+	}
+	return conf.Bytes()
+}
+
+// MongoDBFormat is used by the Vault encryption of Percona Server for
+// MongoDB, which supports only the KV version 2 engine. mongod is
+// configured with security.vault.{tokenFile,serverName,port,secret,serverCAFile}
+// from the token, serverName, port, secret and ca.crt keys.
+type MongoDBFormat struct{}
+
+func (MongoDBFormat) Policy(kv vault.KV, path string) (string, error) {
+	if kv.Version != 2 {
+		return "", errors.Errorf("percona server for mongodb requires kv version 2, %s is version %d", kv.Mount, kv.Version)
+	}
+	return kv.Policy(path), nil
+}
+
+func (MongoDBFormat) SecretData(token string, rootConf vault.Conf, kv vault.KV, path string) (map[string][]byte, error) {
+	serverName, port, err := serverAddress(rootConf.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string][]byte{
+		"token":      []byte(token),
+		"serverName": []byte(serverName),
+		"port":       []byte(port),
+		"secret":     []byte(kv.DataPath(path)),
+	}
+	if rootConf.Cert != nil {
+		data["ca.crt"] = rootConf.Cert
+	}
+
+	return data, nil
+}
+
+func (MongoDBFormat) Token(data map[string][]byte) (string, error) {
+	return tokenKey(data)
+}
+
+// ReadConf returns the logical path of the secret's data path, the mount
+// is the part before the first data/.
+func (MongoDBFormat) ReadConf(c client.Client, namespace, secretName string) (vault.Conf, error) {
+	conf, err := vault.MongoDBConfFrom(c, namespace, secretName)
+	if err != nil {
+		return conf, err
+	}
+
+	if !strings.Contains(conf.SecretMountPoint, "/data/") {
+		return conf, errors.Errorf("secret %s isn't a kv version 2 data path", conf.SecretMountPoint)
+	}
+	conf.SecretMountPoint = strings.Replace(conf.SecretMountPoint, "/data/", "/", 1)
+
+	return conf, nil
+}
+
+func (MongoDBFormat) TransferKeys(from, to transfer.Location, opts transfer.Options) ([]transfer.KeyResult, error) {
+	return transfer.MasterKeys(from, to, opts)
+}
+
+// serverAddress splits the Vault URL into the host and port,
+// the port defaults to the scheme's one.
+func serverAddress(vaultURL string) (string, string, error) {
+	u, err := url.Parse(vaultURL)
+	if err != nil {
+		return "", "", errors.Wrap(err, "parse vault url")
+	}
+	if u.Hostname() == "" {
+		return "", "", errors.Errorf("no host in vault url %q", vaultURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	return u.Hostname(), port, nil
+}
+
+// PlainFormat writes every value under its own key: token (or
+// vault_auth_path and vault_role), vault_url, secret_path, kv_version and
+// ca.crt. Key transfers aren't supported.
+type PlainFormat struct{}
+
+func (PlainFormat) Policy(kv vault.KV, path string) (string, error) {
+	return kv.Policy(path), nil
+}
+
+func (PlainFormat) SecretData(token string, rootConf vault.Conf, kv vault.KV, path string) (map[string][]byte, error) {
+	data := map[string][]byte{
+		"token":       []byte(token),
+		"vault_url":   []byte(rootConf.URL),
+		"secret_path": []byte(path),
+		"kv_version":  []byte(fmt.Sprint(kv.Version)),
+	}
+	if rootConf.Cert != nil {
+		data["ca.crt"] = rootConf.Cert
+	}
+
+	return data, nil
+}
+
+func (PlainFormat) Token(data map[string][]byte) (string, error) {
+	return tokenKey(data)
+}
+
+func (PlainFormat) RoleSecretData(role vault.KubernetesRole, rootConf vault.Conf, path string) map[string][]byte {
+	data := map[string][]byte{
+		"vault_url":       []byte(rootConf.URL),
+		"vault_auth_path": []byte(role.AuthPath),
+		"vault_role":      []byte(role.Name),
+		"secret_path":     []byte(path),
+	}
+	if rootConf.Cert != nil {
+		data["ca.crt"] = rootConf.Cert
+	}
+
+	return data
+}
+
+func (PlainFormat) ReadConf(c client.Client, namespace, secretName string) (vault.Conf, error) {
+	return vault.Conf{}, errors.New("key transfers aren't supported")
+}
+
+func (PlainFormat) TransferKeys(from, to transfer.Location, opts transfer.Options) ([]transfer.KeyResult, error) {
+	return nil, errors.New("key transfers aren't supported")
+}
+
+// tokenKey returns the token of formats keeping it under the token key.
+func tokenKey(data map[string][]byte) (string, error) {
+	token := string(data["token"])
+	if token == "" {
+		return "", errors.New("can't find vault token in secret")
+	}
+	return token, nil
+}
@@ -0,0 +1,110 @@
+// Package issuer contains the issuance flow shared by the controllers of
+// database clusters: the annotation contract, token options and patches of
+// the cluster objects.
+package issuer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// Cluster annotations.
+const (
+	// IssueAnnotation requests a Vault token for the cluster.
+	IssueAnnotation = "percona.com/issue-vault-token"
+	// TransferKeysAnnotation lists name.namespace of clusters to copy keys from.
+	TransferKeysAnnotation = "percona.com/vault-transfer-keys"
+	// TransferAllVersionsAnnotation set to "true" copies every version of
+	// keys in a KV version 2 engine, only the current one otherwise.
+	TransferAllVersionsAnnotation = "percona.com/vault-transfer-all-versions"
+	// TransferConflictAnnotation is the conflict policy of transfers:
+	// Overwrite (default), SkipExisting or FailOnDifference.
+	TransferConflictAnnotation = "percona.com/vault-transfer-conflict-policy"
+	// TransferMoveAnnotation set to "true" deletes transferred keys from the
+	// source, which must allow it with transfer.AllowMoveAnnotation.
+	TransferMoveAnnotation = "percona.com/vault-transfer-move"
+
+	// Token options overriding the operator-wide ones.
+	TokenTTLAnnotation    = "percona.com/vault-token-ttl"
+	TokenMaxTTLAnnotation = "percona.com/vault-token-max-ttl"
+	TokenPeriodAnnotation = "percona.com/vault-token-period"
+)
+
+// TokenAccessorAnnotation holds the accessor of the token written to
+// the issued secret.
+const TokenAccessorAnnotation = "percona.com/vault-token-accessor"
+
+// TokenFinalizer is set on clusters the issuer created a Vault token for,
+// so the token and policy can be revoked before the cluster object is gone.
+const TokenFinalizer = "percona.com/revoke-vault-token"
+
+const (
+	// DataRetain keeps the cluster's secrets in Vault after the cluster is deleted.
+	DataRetain = "retain"
+	// DataDelete purges the cluster's Vault path after the cluster is deleted.
+	DataDelete = "delete"
+)
+
+// PolicyName is the name of the policy and role issued for the secret.
+// Namespaces can't contain ".", so names of different secrets never clash.
+func PolicyName(namespace, secretName string) string {
+	return fmt.Sprintf("%s.%s", namespace, secretName)
+}
+
+// TokenOptions returns defaults overridden by the object's annotations.
+func TokenOptions(o metav1.Object, defaults vault.TokenOptions) (vault.TokenOptions, error) {
+	opts := defaults
+
+	for annotation, d := range map[string]*time.Duration{
+		TokenTTLAnnotation:    &opts.TTL,
+		TokenMaxTTLAnnotation: &opts.MaxTTL,
+		TokenPeriodAnnotation: &opts.Period,
+	} {
+		val, ok := o.GetAnnotations()[annotation]
+		if !ok {
+			continue
+		}
+
+		v, err := time.ParseDuration(val)
+		if err != nil {
+			return vault.TokenOptions{}, errors.Wrapf(err, "parse %s annotation", annotation)
+		}
+		*d = v
+	}
+
+	return opts, nil
+}
+
+// IssueToken writes the policy and creates a token bound to it.
+func IssueToken(cl *api.Client, policyName, policy string, opts vault.TokenOptions) (*api.Secret, error) {
+	err := cl.Sys().PutPolicy(policyName, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to put policy")
+	}
+
+	return vault.CreateToken(cl, policyName, opts)
+}
+
+// RevokeToken revokes the token with the accessor, if any, and deletes
+// the policy.
+func RevokeToken(cl *api.Client, accessor, policyName string) error {
+	if accessor != "" {
+		err := vault.RevokeAccessor(cl, accessor, policyName)
+		if err != nil {
+			return errors.Wrap(err, "revoke token")
+		}
+	}
+
+	err := cl.Sys().DeletePolicy(policyName)
+	if err != nil {
+		return errors.Wrap(err, "delete policy")
+	}
+
+	return nil
+}
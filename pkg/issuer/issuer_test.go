@@ -0,0 +1,28 @@
+package issuer
+
+import "testing"
+
+func TestPolicyName(t *testing.T) {
+	tests := []struct {
+		namespace  string
+		secretName string
+		want       string
+	}{
+		{"ns", "vault", "ns.vault"},
+		{"a", "b-c", "a.b-c"},
+		{"a-b", "c", "a-b.c"},
+		{"a", "b.c", "a.b.c"},
+	}
+
+	seen := make(map[string]bool)
+	for _, tt := range tests {
+		got := PolicyName(tt.namespace, tt.secretName)
+		if got != tt.want {
+			t.Errorf("PolicyName(%q, %q) = %q, want %q", tt.namespace, tt.secretName, got, tt.want)
+		}
+		if seen[got] {
+			t.Errorf("PolicyName(%q, %q) = %q clashes with another secret", tt.namespace, tt.secretName, got)
+		}
+		seen[got] = true
+	}
+}
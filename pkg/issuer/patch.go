@@ -0,0 +1,104 @@
+package issuer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Cluster objects belong to other operators and the issuer has only partial
+// types for them, so they are changed with patches rather than updates.
+
+// DeleteAnnotation removes the annotation from the object.
+func DeleteAnnotation(c client.Client, o runtime.Object, annotation string) error {
+	annotation = strings.Replace(annotation, "/", "~1", -1)
+	return c.Patch(context.Background(), o, client.RawPatch(types.JSONPatchType, []byte(fmt.Sprintf("[{\"op\": \"remove\", \"path\": \"/metadata/annotations/%s\"}]", annotation))))
+}
+
+// ReplaceAnnotation sets the value of the existing annotation.
+func ReplaceAnnotation(c client.Client, o runtime.Object, annotation, value string) error {
+	annotation = strings.Replace(annotation, "/", "~1", -1)
+	return c.Patch(context.TODO(), o, client.RawPatch(types.JSONPatchType, []byte(fmt.Sprintf(
+		`[{"op": "replace", "path": "/metadata/annotations/%s", "value": %q}]`, annotation, value))))
+}
+
+// AddFinalizer adds the finalizer if the object doesn't have it yet.
+func AddFinalizer(c client.Client, o controllerutil.Object, finalizer string) error {
+	if controllerutil.ContainsFinalizer(o, finalizer) {
+		return nil
+	}
+
+	orig := o.DeepCopyObject()
+	controllerutil.AddFinalizer(o, finalizer)
+	return c.Patch(context.TODO(), o, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+// RemoveFinalizer removes the finalizer from the object.
+func RemoveFinalizer(c client.Client, o controllerutil.Object, finalizer string) error {
+	orig := o.DeepCopyObject()
+	controllerutil.RemoveFinalizer(o, finalizer)
+	return c.Patch(context.TODO(), o, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+// SwapAnnotation sets the annotation to the new value, or removes it with
+// the others if new is empty, only if it still has the old value. The
+// value is compared and changed in a single patch, so concurrent changes
+// make the patch fail.
+func SwapAnnotation(c client.Client, o runtime.Object, annotation, old, new string, others ...string) error {
+	ops := []map[string]string{
+		{"op": "test", "path": annotationPath(annotation), "value": old},
+	}
+	if new != "" {
+		ops = append(ops, map[string]string{"op": "replace", "path": annotationPath(annotation), "value": new})
+	} else {
+		for _, a := range append([]string{annotation}, others...) {
+			ops = append(ops, map[string]string{"op": "remove", "path": annotationPath(a)})
+		}
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	return c.Patch(context.TODO(), o, client.RawPatch(types.JSONPatchType, patch))
+}
+
+// SetAnnotations adds or replaces the annotations.
+func SetAnnotations(c client.Client, o runtime.Object, annotations map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	return c.Patch(context.TODO(), o, client.RawPatch(types.MergePatchType, patch))
+}
+
+func annotationPath(annotation string) string {
+	return "/metadata/annotations/" + strings.Replace(annotation, "/", "~1", -1)
+}
+
+// RemoveAnnotations removes those of the annotations the object has.
+func RemoveAnnotations(c client.Client, o controllerutil.Object, annotations ...string) error {
+	ops := make([]map[string]string, 0, len(annotations))
+	for _, a := range annotations {
+		if _, ok := o.GetAnnotations()[a]; ok {
+			ops = append(ops, map[string]string{"op": "remove", "path": annotationPath(a)})
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	return c.Patch(context.TODO(), o, client.RawPatch(types.JSONPatchType, patch))
+}
@@ -0,0 +1,177 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// DryRunAnnotation set to "true" on a cluster makes the issue and transfer
+// annotations report what would be done, nothing is written to Vault or
+// Kubernetes then. The annotations are consumed by the plan.
+const DryRunAnnotation = "percona.com/vault-dry-run"
+
+// DryRun reports whether the object requests dry runs.
+func DryRun(o metav1.Object) bool {
+	return o.GetAnnotations()[DryRunAnnotation] == "true"
+}
+
+// PlanIssue does all reads and checks of ProcessIssueAnnotation and returns
+// what would be issued.
+func (i *Issuer) PlanIssue(c Cluster) (string, error) {
+	secret, err := i.GetSecret(c)
+	if err != nil {
+		return "", err
+	}
+
+	ns, secretName := c.Object().GetNamespace(), c.SecretName()
+	if secret != nil {
+		return fmt.Sprintf("secret %s/%s exists, nothing would be issued", ns, secretName), nil
+	}
+
+	opts, err := TokenOptions(c.Object(), i.TokenOptions)
+	if err != nil {
+		return "", errors.Wrap(err, "get token options")
+	}
+
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return "", err
+	}
+
+	path := vault.SecretPath(rootConf.SecretMountPoint, ns, secretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return "", err
+	}
+	_, err = c.Format().Policy(kv, path)
+	if err != nil {
+		return "", err
+	}
+
+	policyName := PolicyName(ns, secretName)
+	err = vault.CheckCapabilities(cl, "sys/policies/acl/"+policyName, "create", "update")
+	if err != nil {
+		return "", err
+	}
+	err = vault.CheckCapabilities(cl, "auth/token/create", "update", "sudo")
+	if err != nil {
+		return "", err
+	}
+
+	data, err := c.Format().SecretData("", rootConf, kv, path)
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	plan := fmt.Sprintf("would write policy %s for %s, create a token (ttl %s, max ttl %s, period %s) and secret %s/%s with %s",
+		policyName, strings.Join(kv.Paths(path), " and "), opts.TTL, opts.MaxTTL, opts.Period, ns, secretName, strings.Join(keys, ", "))
+
+	switch mode := i.issueMode(c.Object()); mode {
+	case IssueModeToken:
+	case IssueModeKubernetesRole:
+		role, err := i.planRole(c)
+		if err != nil {
+			return "", err
+		}
+		plan += role
+	default:
+		return "", errors.Errorf("unknown issue mode %q", mode)
+	}
+
+	return plan, nil
+}
+
+// PlanTransfer does all reads and checks of ProcessTransferAnnotation and
+// returns what would be copied from every source cluster.
+func (i *Issuer) PlanTransfer(dst Cluster, sources string, newCluster func() Cluster) ([]string, error) {
+	opts, err := transferOptions(dst)
+	if err != nil {
+		return nil, err
+	}
+	opts.DryRun = true
+
+	plan := make([]string, 0)
+	for _, v := range sourceClusters(sources) {
+		src, err := i.sourceCluster(v, newCluster)
+		if err != nil {
+			plan = append(plan, fmt.Sprintf("%s: %v", v, err))
+			continue
+		}
+
+		results, err := i.TransferKeyResults(src, dst, opts)
+		if err != nil {
+			plan = append(plan, fmt.Sprintf("%s: %v", v, err))
+			continue
+		}
+
+		count := make(map[transfer.Result]int)
+		for _, res := range results {
+			count[res.Result]++
+			i.Log.Info("planned key transfer", "src cluster", v, "key", res.Key, "result", res.Result, "message", res.Message)
+		}
+		plan = append(plan, fmt.Sprintf("%s: %d keys would be copied, %d skipped, %d failed",
+			v, count[transfer.ResultPlanned], count[transfer.ResultSkipped], count[transfer.ResultFailed]))
+	}
+
+	return plan, nil
+}
+
+// transferOptions are set by annotations of the destination cluster.
+func transferOptions(dst Cluster) (transfer.Options, error) {
+	conflict, err := transfer.ParseConflictPolicy(dst.Object().GetAnnotations()[TransferConflictAnnotation])
+	if err != nil {
+		return transfer.Options{}, errors.Wrapf(err, "parse %s annotation", TransferConflictAnnotation)
+	}
+
+	return transfer.Options{
+		Conflict:    conflict,
+		AllVersions: dst.Object().GetAnnotations()[TransferAllVersionsAnnotation] == "true",
+		Move:        dst.Object().GetAnnotations()[TransferMoveAnnotation] == "true",
+	}, nil
+}
+
+// sourceClusters splits the annotation value, duplicates are dropped.
+func sourceClusters(sources string) []string {
+	seen := make(map[string]bool)
+	clusters := make([]string, 0)
+	for _, v := range strings.Split(sources, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		clusters = append(clusters, v)
+	}
+
+	return clusters
+}
+
+// sourceCluster reads the cluster named name.namespace.
+func (i *Issuer) sourceCluster(name string, newCluster func() Cluster) (Cluster, error) {
+	c, err := transfer.ParseCluster(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid source cluster name")
+	}
+
+	src := newCluster()
+	err = i.Client.Get(context.TODO(), client.ObjectKey{Namespace: c.Namespace, Name: c.Name}, src.Object())
+	if err != nil {
+		return nil, errors.Wrap(err, "get cluster definition")
+	}
+
+	return src, nil
+}
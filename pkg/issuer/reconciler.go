@@ -0,0 +1,186 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Reconciler handles the issuer annotations of clusters of a single kind:
+// it issues, rotates and revokes their secrets and transfers keys. A new
+// database needs only a Cluster adapter.
+type Reconciler struct {
+	Issuer *Issuer
+	Log    logr.Logger
+	// Scheme is used for owner references of the status ConfigMaps.
+	Scheme *runtime.Scheme
+	// Recorder records Events on clusters.
+	Recorder record.EventRecorder
+	// NewCluster returns an empty cluster of the reconciled kind.
+	NewCluster func() Cluster
+	// Renewer keeps issued tokens alive, optional. It is added to
+	// the manager with the controller.
+	Renewer *TokenRenewer
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("cluster", req.NamespacedName)
+
+	rr := reconcile.Result{
+		RequeueAfter: time.Second * 5,
+	}
+
+	c := r.NewCluster()
+	o := c.Object()
+	err := r.Issuer.Client.Get(context.TODO(), req.NamespacedName, o)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return rr, err
+	}
+
+	if o.GetDeletionTimestamp() != nil {
+		if !controllerutil.ContainsFinalizer(o, TokenFinalizer) {
+			return reconcile.Result{}, nil
+		}
+
+		log.Info("revoking vault token")
+		err = r.Issuer.Revoke(c)
+		r.reportResult(o, conditionVaultTokenRevoked, err, "vault token and policy are revoked")
+		if err != nil {
+			return rr, errors.Wrap(err, "revoke vault token")
+		}
+
+		if r.Renewer != nil {
+			r.Renewer.Untrack(req.NamespacedName)
+		}
+
+		return reconcile.Result{}, RemoveFinalizer(r.Issuer.Client, o, TokenFinalizer)
+	}
+
+	if _, ok := o.GetAnnotations()[IssueAnnotation]; ok {
+		err = r.issue(c)
+		if err != nil {
+			r.reportResult(o, conditionVaultTokenIssued, err, "")
+			return rr, errors.Wrap(err, "issue vault token")
+		}
+	}
+
+	if controllerutil.ContainsFinalizer(o, TokenFinalizer) {
+		accessor, err := r.Issuer.ProcessRotation(c)
+		if err != nil {
+			r.reportResult(o, conditionVaultTokenRotated, err, "")
+			return rr, errors.Wrap(err, "rotate vault token")
+		}
+		if accessor != "" {
+			r.reportResult(o, conditionVaultTokenRotated, nil, "vault token is rotated, the previous one is revoked after the grace period")
+		}
+
+		err = r.track(c)
+		if err != nil {
+			return rr, errors.Wrap(err, "track vault token")
+		}
+	}
+
+	if val, ok := o.GetAnnotations()[TransferKeysAnnotation]; ok {
+		log.Info("copying keys")
+		err = r.transfer(c, val)
+		if err != nil {
+			return rr, errors.Wrap(err, "transfer vault keys")
+		}
+	}
+
+	return rr, nil
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Renewer != nil {
+		err := mgr.Add(r.Renewer)
+		if err != nil {
+			return errors.Wrap(err, "add token renewer")
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(r.NewCluster().Object()).
+		Complete(r)
+}
+
+func (r *Reconciler) issue(c Cluster) error {
+	o := c.Object()
+	if DryRun(o) {
+		plan, err := r.Issuer.PlanIssue(c)
+		if err != nil {
+			return err
+		}
+		r.reportResult(o, conditionVaultTokenIssuePlanned, nil, plan)
+		return DeleteAnnotation(r.Issuer.Client, o, IssueAnnotation)
+	}
+
+	issued, err := r.Issuer.ProcessIssueAnnotation(c)
+	if err != nil {
+		return err
+	}
+	if issued {
+		r.reportResult(o, conditionVaultTokenIssued, nil, fmt.Sprintf("issued %s secret in %s mode", c.SecretName(), r.Issuer.issueMode(o)))
+	}
+
+	return nil
+}
+
+func (r *Reconciler) transfer(c Cluster, sources string) error {
+	o := c.Object()
+	if DryRun(o) {
+		plan, err := r.Issuer.PlanTransfer(c, sources, r.NewCluster)
+		r.reportResult(o, conditionTransitionKeysTransferPlanned, err, strings.Join(plan, "; "))
+		if err != nil {
+			return err
+		}
+		return DeleteAnnotation(r.Issuer.Client, o, TransferKeysAnnotation)
+	}
+
+	transferred, failures, err := r.Issuer.ProcessTransferAnnotation(c, sources, r.NewCluster)
+	checksums := ""
+	if len(transferred) > 0 {
+		checksums = ", checksums: " + strings.Join(transferred, ", ")
+	}
+	switch {
+	case len(failures) > 0:
+		r.reportResult(o, conditionTransitionKeysTransferred, errors.New(strings.Join(failures, "; ")+checksums), "")
+	case err == nil:
+		r.reportResult(o, conditionTransitionKeysTransferred, nil, "keys are copied from "+sources+checksums)
+	}
+
+	return err
+}
+
+// track passes the token written to the cluster's issued secret
+// to the renewer.
+func (r *Reconciler) track(c Cluster) error {
+	if r.Renewer == nil {
+		return nil
+	}
+
+	secret, err := r.Issuer.GetSecret(c)
+	if err != nil || secret == nil {
+		return err
+	}
+
+	if accessor, ok := secret.Annotations[TokenAccessorAnnotation]; ok {
+		r.Renewer.Track(types.NamespacedName{Namespace: c.Object().GetNamespace(), Name: c.Object().GetName()}, accessor)
+	}
+
+	return nil
+}
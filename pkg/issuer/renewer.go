@@ -0,0 +1,157 @@
+package issuer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// TokenRenewer keeps tokens issued for clusters of a single kind alive.
+// Every Interval it renews tokens that have less than half of their TTL left
+// and re-issues the ones that can't be renewed anymore.
+type TokenRenewer struct {
+	Issuer *Issuer
+	// NewCluster returns an empty cluster of the renewed kind.
+	NewCluster func() Cluster
+	Interval   time.Duration
+	Log        logr.Logger
+
+	mu     sync.Mutex
+	tokens map[types.NamespacedName]string
+}
+
+// Track registers accessor of the token issued for the cluster.
+func (t *TokenRenewer) Track(cluster types.NamespacedName, accessor string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tokens == nil {
+		t.tokens = make(map[types.NamespacedName]string)
+	}
+	t.tokens[cluster] = accessor
+}
+
+// Untrack stops renewal of the token issued for the cluster.
+func (t *TokenRenewer) Untrack(cluster types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.tokens, cluster)
+}
+
+// Start implements manager.Runnable.
+func (t *TokenRenewer) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			t.renewAll()
+		}
+	}
+}
+
+func (t *TokenRenewer) renewAll() {
+	t.mu.Lock()
+	tokens := make(map[types.NamespacedName]string, len(t.tokens))
+	for k, v := range t.tokens {
+		tokens[k] = v
+	}
+	t.mu.Unlock()
+
+	for cluster, accessor := range tokens {
+		err := t.renew(cluster, accessor)
+		if err != nil {
+			t.Log.Error(err, "can't renew vault token", "cluster", cluster)
+		}
+	}
+}
+
+func (t *TokenRenewer) renew(cluster types.NamespacedName, accessor string) error {
+	c := t.NewCluster()
+	err := t.Issuer.Client.Get(context.TODO(), cluster, c.Object())
+	if apierrors.IsNotFound(err) {
+		t.Untrack(cluster)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "get cluster")
+	}
+
+	cl, _, err := t.Issuer.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	sec, err := cl.Auth().Token().LookupAccessor(accessor)
+	if vault.IsInvalidAccessor(err) {
+		t.Log.Info("vault token is expired or revoked, re-issuing", "cluster", cluster)
+		return t.reissue(c)
+	}
+	if err != nil {
+		return errors.Wrap(err, "lookup accessor")
+	}
+
+	ttl, err := sec.TokenTTL()
+	if err != nil {
+		return errors.Wrap(err, "get token ttl")
+	}
+	if ttl == 0 {
+		// the token never expires
+		return nil
+	}
+
+	creationTTL, err := vault.SecondsFrom(sec.Data["creation_ttl"])
+	if err != nil {
+		return errors.Wrap(err, "get token creation ttl")
+	}
+	if ttl > creationTTL/2 && ttl > 2*t.Interval {
+		return nil
+	}
+
+	secret, err := t.Issuer.GetSecret(c)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return errors.New("token secret not found")
+	}
+
+	token, err := c.Format().Token(secret.Data)
+	if err != nil {
+		return err
+	}
+
+	renewed, err := cl.Auth().Token().Renew(token, 0)
+	if err != nil {
+		t.Log.Info("can't renew vault token, re-issuing", "cluster", cluster, "err", err)
+		return t.reissue(c)
+	}
+
+	if time.Duration(renewed.Auth.LeaseDuration)*time.Second <= 2*t.Interval {
+		t.Log.Info("vault token reached its max ttl, re-issuing", "cluster", cluster)
+		return t.reissue(c)
+	}
+
+	return nil
+}
+
+func (t *TokenRenewer) reissue(c Cluster) error {
+	accessor, err := t.Issuer.ReissueToken(c)
+	if err != nil {
+		return errors.Wrap(err, "reissue token")
+	}
+
+	t.Track(types.NamespacedName{Namespace: c.Object().GetNamespace(), Name: c.Object().GetName()}, accessor)
+	return nil
+}
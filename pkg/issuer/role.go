@@ -0,0 +1,134 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+const (
+	// IssueModeToken writes a token to the issued secret.
+	IssueModeToken = "token"
+	// IssueModeKubernetesRole creates a Vault Kubernetes auth role bound to the
+	// cluster's ServiceAccount and writes the role description to the issued
+	// secret. Only formats implementing RoleFormat support it.
+	IssueModeKubernetesRole = "kubernetes-role"
+)
+
+const (
+	// IssueModeAnnotation overrides the operator-wide issue mode on the
+	// cluster, the issued secret is annotated with the mode it was issued in.
+	IssueModeAnnotation = "percona.com/vault-issue-mode"
+	// ServiceAccountAnnotation is the ServiceAccount the role is bound to,
+	// "default" if not set.
+	ServiceAccountAnnotation = "percona.com/vault-service-account"
+)
+
+// issueMode returns the mode the cluster's secret is issued in.
+func (i *Issuer) issueMode(o metav1.Object) string {
+	if val, ok := o.GetAnnotations()[IssueModeAnnotation]; ok {
+		return val
+	}
+	if i.IssueMode == "" {
+		return IssueModeToken
+	}
+	return i.IssueMode
+}
+
+// IssueRole writes the cluster's policy, a Kubernetes auth role bound to it
+// and the cluster's secret describing the role.
+func (i *Issuer) IssueRole(c Cluster, opts vault.TokenOptions) error {
+	format, ok := c.Format().(RoleFormat)
+	if !ok {
+		return errors.Errorf("%s mode isn't supported by the cluster", IssueModeKubernetesRole)
+	}
+
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	o := c.Object()
+	ns, secretName := o.GetNamespace(), c.SecretName()
+	path := vault.SecretPath(rootConf.SecretMountPoint, ns, secretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return err
+	}
+	policy, err := c.Format().Policy(kv, path)
+	if err != nil {
+		return err
+	}
+
+	policyName := PolicyName(ns, secretName)
+	err = cl.Sys().PutPolicy(policyName, policy)
+	if err != nil {
+		return errors.Wrap(err, "failed to put policy")
+	}
+
+	serviceAccount := "default"
+	if val, ok := o.GetAnnotations()[ServiceAccountAnnotation]; ok {
+		serviceAccount = val
+	}
+
+	role := vault.KubernetesRole{
+		AuthPath:       vault.KubernetesAuthPath(i.KubernetesAuthPath),
+		Name:           policyName,
+		ServiceAccount: serviceAccount,
+		Namespace:      ns,
+		Policy:         policyName,
+		Options:        opts,
+	}
+	err = vault.WriteKubernetesRole(cl, role)
+	if err != nil {
+		return err
+	}
+
+	err = i.Client.Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: ns,
+			Annotations: map[string]string{
+				IssueModeAnnotation: IssueModeKubernetesRole,
+			},
+		},
+		Data: format.RoleSecretData(role, rootConf, path),
+		Type: corev1.SecretTypeOpaque,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create role secret")
+	}
+
+	return nil
+}
+
+// deleteRole deletes the cluster's role. It is deleted if the secret is
+// gone as well, as it may have been issued in IssueModeKubernetesRole.
+func (i *Issuer) deleteRole(cl *api.Client, c Cluster, secret *corev1.Secret) error {
+	if secret != nil && secret.Annotations[IssueModeAnnotation] != IssueModeKubernetesRole {
+		return nil
+	}
+
+	authPath := vault.KubernetesAuthPath(i.KubernetesAuthPath)
+	if secret != nil && len(secret.Data["vault_auth_path"]) > 0 {
+		authPath = string(secret.Data["vault_auth_path"])
+	}
+
+	err := vault.DeleteKubernetesRole(cl, authPath, PolicyName(c.Object().GetNamespace(), c.SecretName()))
+	return errors.Wrap(err, "delete role")
+}
+
+// planRole is appended to the plan of the issue in IssueModeKubernetesRole.
+func (i *Issuer) planRole(c Cluster) (string, error) {
+	if _, ok := c.Format().(RoleFormat); !ok {
+		return "", errors.Errorf("%s mode isn't supported by the cluster", IssueModeKubernetesRole)
+	}
+	return fmt.Sprintf(", in %s mode a role in %s would be written instead of the token",
+		IssueModeKubernetesRole, vault.KubernetesAuthPath(i.KubernetesAuthPath)), nil
+}
@@ -0,0 +1,188 @@
+package issuer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// Cluster annotations controlling token rotation.
+const (
+	// RotateAnnotation requests a rotation, it is removed once the token is rotated.
+	RotateAnnotation = "percona.com/rotate-vault-token"
+	// RotationIntervalAnnotation overrides the operator-wide rotation interval.
+	RotationIntervalAnnotation = "percona.com/vault-token-rotation-interval"
+)
+
+// Annotations set on the issued secret.
+const (
+	// PrevTokenAccessorAnnotation holds the accessor of the replaced token
+	// that is revoked once PrevTokenRevokeAtAnnotation time is reached.
+	PrevTokenAccessorAnnotation = "percona.com/vault-previous-token-accessor"
+	PrevTokenRevokeAtAnnotation = "percona.com/vault-previous-token-revoke-at"
+)
+
+// ProcessRotation rotates the cluster's token if it is requested by the
+// annotation or the rotation interval is over, and revokes the replaced
+// token once its grace period is over. It returns accessor of the new
+// token, empty if the token wasn't rotated.
+func (i *Issuer) ProcessRotation(c Cluster) (string, error) {
+	secret, err := i.GetSecret(c)
+	if err != nil || secret == nil {
+		return "", err
+	}
+
+	log := i.Log.WithValues("cluster", namespacedName(c))
+	if _, ok := secret.Annotations[PrevTokenAccessorAnnotation]; ok {
+		return "", i.revokePreviousToken(c, secret)
+	}
+
+	o := c.Object()
+	_, requested := o.GetAnnotations()[RotateAnnotation]
+
+	if secret.Annotations[IssueModeAnnotation] == IssueModeKubernetesRole {
+		if requested {
+			log.Info("no vault token to rotate, the secret describes kubernetes auth role")
+			return "", DeleteAnnotation(i.Client, o, RotateAnnotation)
+		}
+		return "", nil
+	}
+
+	interval := i.TokenRotationInterval
+	if val, ok := o.GetAnnotations()[RotationIntervalAnnotation]; ok {
+		interval, err = time.ParseDuration(val)
+		if err != nil {
+			return "", errors.Wrapf(err, "parse %s annotation", RotationIntervalAnnotation)
+		}
+	}
+
+	due := false
+	if interval > 0 {
+		issuedAt, err := time.Parse(time.RFC3339, secret.Annotations[TokenIssuedAtAnnotation])
+		if err != nil {
+			// secrets issued before rotation was supported
+			issuedAt = secret.CreationTimestamp.Time
+		}
+		due = time.Now().After(issuedAt.Add(interval))
+	}
+
+	if !requested && !due {
+		return "", nil
+	}
+
+	log.Info("rotating vault token")
+	accessor, err := i.ReissueToken(c)
+	if err != nil {
+		return "", errors.Wrap(err, "reissue token")
+	}
+
+	if requested {
+		return accessor, DeleteAnnotation(i.Client, o, RotateAnnotation)
+	}
+
+	return accessor, nil
+}
+
+// ReissueToken creates a new token with the cluster's policy and writes it
+// to the issued secret in a single update. The replaced token is scheduled
+// for revocation after the rotation grace period.
+// It returns accessor of the new token.
+func (i *Issuer) ReissueToken(c Cluster) (string, error) {
+	secret, err := i.GetSecret(c)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil {
+		return "", errors.New("token secret not found")
+	}
+
+	opts, err := TokenOptions(c.Object(), i.TokenOptions)
+	if err != nil {
+		return "", errors.Wrap(err, "get token options")
+	}
+
+	cl, rootConf, err := i.Root.Login()
+	if err != nil {
+		return "", err
+	}
+
+	ns, secretName := c.Object().GetNamespace(), c.SecretName()
+	path := vault.SecretPath(rootConf.SecretMountPoint, ns, secretName)
+	kv, err := vault.DetectKV(cl, path)
+	if err != nil {
+		return "", err
+	}
+
+	policyName := PolicyName(ns, secretName)
+	sec, err := vault.CreateToken(cl, policyName, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if prev, ok := secret.Annotations[PrevTokenAccessorAnnotation]; ok {
+		// the token replaced before the previous grace period is over
+		// shouldn't outlive the token which replaced it
+		err = vault.RevokeAccessor(cl, prev, policyName)
+		if err != nil {
+			i.Log.Error(err, "can't revoke previous vault token", "secret", secretName, "namespace", ns)
+		}
+	}
+
+	data, err := c.Format().SecretData(sec.Auth.ClientToken, rootConf, kv, path)
+	if err == nil {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		for k, v := range data {
+			secret.Data[k] = v
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		now := time.Now().UTC()
+		if accessor, ok := secret.Annotations[TokenAccessorAnnotation]; ok {
+			secret.Annotations[PrevTokenAccessorAnnotation] = accessor
+			secret.Annotations[PrevTokenRevokeAtAnnotation] = now.Add(i.TokenRotationGracePeriod).Format(time.RFC3339)
+		}
+		secret.Annotations[TokenAccessorAnnotation] = sec.Auth.Accessor
+		secret.Annotations[TokenIssuedAtAnnotation] = now.Format(time.RFC3339)
+
+		err = errors.Wrap(i.Client.Update(context.TODO(), secret), "update token secret")
+	}
+	if err != nil {
+		if rerr := cl.Auth().Token().RevokeAccessor(sec.Auth.Accessor); rerr != nil {
+			i.Log.Error(rerr, "can't revoke unused vault token", "secret", secretName, "namespace", ns)
+		}
+		return "", err
+	}
+
+	return sec.Auth.Accessor, nil
+}
+
+// revokePreviousToken revokes the token replaced by the rotation
+// once its grace period is over. Until then no new rotation is started.
+func (i *Issuer) revokePreviousToken(c Cluster, secret *corev1.Secret) error {
+	revokeAt, err := time.Parse(time.RFC3339, secret.Annotations[PrevTokenRevokeAtAnnotation])
+	if err == nil && time.Now().Before(revokeAt) {
+		return nil
+	}
+
+	cl, _, err := i.Root.Login()
+	if err != nil {
+		return err
+	}
+
+	i.Log.Info("revoking previous vault token", "cluster", namespacedName(c))
+	err = vault.RevokeAccessor(cl, secret.Annotations[PrevTokenAccessorAnnotation], PolicyName(c.Object().GetNamespace(), c.SecretName()))
+	if err != nil {
+		return errors.Wrap(err, "revoke previous token")
+	}
+
+	delete(secret.Annotations, PrevTokenAccessorAnnotation)
+	delete(secret.Annotations, PrevTokenRevokeAtAnnotation)
+	return errors.Wrap(i.Client.Update(context.TODO(), secret), "update token secret")
+}
@@ -0,0 +1,101 @@
+package issuer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Condition types reported for a cluster.
+const (
+	conditionVaultTokenIssued          = "VaultTokenIssued"
+	conditionVaultTokenRotated         = "VaultTokenRotated"
+	conditionVaultTokenRevoked         = "VaultTokenRevoked"
+	conditionTransitionKeysTransferred = "TransitionKeysTransferred"
+	// plans of dry runs
+	conditionVaultTokenIssuePlanned        = "VaultTokenIssuePlanned"
+	conditionTransitionKeysTransferPlanned = "TransitionKeysTransferPlanned"
+)
+
+// condition is stored as JSON in the cluster's status ConfigMap under
+// the key equal to its type.
+type condition struct {
+	Status             metav1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime"`
+}
+
+// statusConfigMapName is the name of the ConfigMap issuance and transfer
+// results are published to. Tenants have access to it, unlike to the
+// operator logs, and the cluster status belongs to the database operator.
+func statusConfigMapName(clusterName string) string {
+	return fmt.Sprintf("%s-vault-issuer", clusterName)
+}
+
+// reportResult records an Event on the cluster and updates the condition in
+// the status ConfigMap. Failures are reported if err is not nil.
+func (r *Reconciler) reportResult(o controllerutil.Object, condType string, err error, message string) {
+	cond := condition{
+		Status:  metav1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: message,
+	}
+	if err != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Failed"
+		cond.Message = err.Error()
+	}
+
+	if cond.Status == metav1.ConditionTrue {
+		r.Recorder.Event(o, corev1.EventTypeNormal, condType, cond.Message)
+	} else {
+		r.Recorder.Event(o, corev1.EventTypeWarning, condType+"Failed", cond.Message)
+	}
+
+	if o.GetDeletionTimestamp() != nil {
+		return
+	}
+
+	if uerr := r.setCondition(o, condType, cond); uerr != nil {
+		r.Log.Error(uerr, "can't update status configmap", "cluster", o.GetName(), "namespace", o.GetNamespace())
+	}
+}
+
+func (r *Reconciler) setCondition(o controllerutil.Object, condType string, cond condition) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statusConfigMapName(o.GetName()),
+			Namespace: o.GetNamespace(),
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.Issuer.Client, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+
+		cond.LastTransitionTime = metav1.Now()
+		if prev, ok := cm.Data[condType]; ok {
+			old := condition{}
+			if json.Unmarshal([]byte(prev), &old) == nil && old.Status == cond.Status {
+				cond.LastTransitionTime = old.LastTransitionTime
+			}
+		}
+
+		data, err := json.Marshal(cond)
+		if err != nil {
+			return err
+		}
+		cm.Data[condType] = string(data)
+
+		return controllerutil.SetOwnerReference(o, cm, r.Scheme)
+	})
+
+	return errors.Wrap(err, "create or update configmap")
+}
@@ -0,0 +1,137 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// Validator is a validating admission webhook rejecting clusters with
+// malformed issuer annotations. Only annotations changed by the request are
+// validated, so objects already having bad ones can still be updated.
+type Validator struct {
+	Client     client.Client
+	NewCluster func() Cluster
+
+	decoder *admission.Decoder
+}
+
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	c := v.NewCluster()
+	err := v.decoder.Decode(req, c.Object())
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	old := v.NewCluster()
+	if req.Operation == admissionv1beta1.Update {
+		err = v.decoder.DecodeRaw(req.OldObject, old.Object())
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	problems, err := v.validate(ctx, c, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(problems) > 0 {
+		return admission.Denied(strings.Join(problems, "; "))
+	}
+
+	return admission.Allowed("")
+}
+
+// validate returns problems of annotations changed from old to c.
+func (v *Validator) validate(ctx context.Context, c, old Cluster) ([]string, error) {
+	annotations := c.Object().GetAnnotations()
+	oldAnnotations := old.Object().GetAnnotations()
+	changed := func(annotation string) bool {
+		val, ok := annotations[annotation]
+		oldVal, oldOk := oldAnnotations[annotation]
+		return ok && (!oldOk || val != oldVal)
+	}
+
+	problems := make([]string, 0)
+	problem := func(annotation string, err error) {
+		problems = append(problems, fmt.Sprintf("invalid %s annotation: %v", annotation, err))
+	}
+
+	if _, ok := annotations[IssueAnnotation]; ok && c.SecretName() == "" &&
+		(changed(IssueAnnotation) || old.SecretName() != "") {
+		problems = append(problems, fmt.Sprintf("%s must be set to use the %s annotation", c.SecretNameField(), IssueAnnotation))
+	}
+
+	if changed(TransferKeysAnnotation) {
+		oldSources := make(map[string]bool)
+		for _, s := range sourceClusters(oldAnnotations[TransferKeysAnnotation]) {
+			oldSources[s] = true
+		}
+
+		for _, s := range sourceClusters(annotations[TransferKeysAnnotation]) {
+			if oldSources[s] {
+				continue
+			}
+			name, err := transfer.ParseCluster(s)
+			if err != nil {
+				problem(TransferKeysAnnotation, err)
+				continue
+			}
+			err = v.Client.Get(ctx, client.ObjectKey{Namespace: name.Namespace, Name: name.Name}, v.NewCluster().Object())
+			if apierrors.IsNotFound(err) {
+				problem(TransferKeysAnnotation, errors.Errorf("cluster %s not found", name))
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, a := range []string{transfer.AllowAnnotation, transfer.AllowMoveAnnotation} {
+		if !changed(a) {
+			continue
+		}
+		if _, err := transfer.ParseAllowlist(annotations[a]); err != nil {
+			problem(a, err)
+		}
+	}
+
+	if changed(transfer.AllowExpiresAnnotation) || changed(transfer.AllowUsesAnnotation) {
+		if _, err := transfer.ParseApproval(annotations); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if changed(TransferConflictAnnotation) {
+		if _, err := transfer.ParseConflictPolicy(annotations[TransferConflictAnnotation]); err != nil {
+			problem(TransferConflictAnnotation, err)
+		}
+	}
+
+	if changed(TokenTTLAnnotation) || changed(TokenMaxTTLAnnotation) || changed(TokenPeriodAnnotation) {
+		if _, err := TokenOptions(c.Object(), vault.TokenOptions{}); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,230 @@
+package issuer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/transfer"
+)
+
+// testCluster keeps its secret name in the secret key of a ConfigMap.
+type testCluster struct {
+	*corev1.ConfigMap
+}
+
+func newTestCluster() Cluster {
+	return testCluster{&corev1.ConfigMap{}}
+}
+
+func (c testCluster) Object() controllerutil.Object {
+	return c.ConfigMap
+}
+
+func (c testCluster) SecretName() string {
+	return c.Data["secret"]
+}
+
+func (c testCluster) SecretNameField() string {
+	return "data.secret"
+}
+
+func (c testCluster) Format() Format {
+	return PlainFormat{}
+}
+
+// fakeClient finds only the clusters it holds, it fails every Get with err
+// if set. Other calls aren't used by the validator.
+type fakeClient struct {
+	client.Client
+	clusters map[client.ObjectKey]bool
+	err      error
+}
+
+func (f fakeClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if f.err != nil {
+		return f.err
+	}
+	if !f.clusters[key] {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	cluster := func(secret string, annotations map[string]string) Cluster {
+		c := testCluster{&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Namespace: "ns", Annotations: annotations},
+		}}
+		if secret != "" {
+			c.Data = map[string]string{"secret": secret}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		c       Cluster
+		old     Cluster
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no annotations",
+			c:    cluster("vault", nil),
+			old:  newTestCluster(),
+		},
+		{
+			name: "issue without secret name",
+			c:    cluster("", map[string]string{IssueAnnotation: "true"}),
+			old:  newTestCluster(),
+			want: []string{"data.secret must be set"},
+		},
+		{
+			name: "issue with secret name",
+			c:    cluster("vault", map[string]string{IssueAnnotation: "true"}),
+			old:  newTestCluster(),
+		},
+		{
+			name: "unchanged issue without secret name",
+			c:    cluster("", map[string]string{IssueAnnotation: "true"}),
+			old:  cluster("", map[string]string{IssueAnnotation: "true"}),
+		},
+		{
+			name: "secret name removed with issue",
+			c:    cluster("", map[string]string{IssueAnnotation: "true"}),
+			old:  cluster("vault", map[string]string{IssueAnnotation: "true"}),
+			want: []string{"data.secret must be set"},
+		},
+		{
+			name: "existing source cluster",
+			c:    cluster("vault", map[string]string{TransferKeysAnnotation: "cluster1.ns"}),
+			old:  newTestCluster(),
+		},
+		{
+			name: "missing source cluster",
+			c:    cluster("vault", map[string]string{TransferKeysAnnotation: "cluster1.ns,cluster3.ns"}),
+			old:  newTestCluster(),
+			want: []string{"cluster cluster3.ns not found"},
+		},
+		{
+			name: "missing source cluster kept from old",
+			c:    cluster("vault", map[string]string{TransferKeysAnnotation: "cluster3.ns,cluster1.ns"}),
+			old:  cluster("vault", map[string]string{TransferKeysAnnotation: "cluster3.ns"}),
+		},
+		{
+			name: "malformed source cluster",
+			c:    cluster("vault", map[string]string{TransferKeysAnnotation: "cluster1"}),
+			old:  newTestCluster(),
+			want: []string{"invalid cluster \"cluster1\""},
+		},
+		{
+			name:    "source cluster lookup fails",
+			c:       cluster("vault", map[string]string{TransferKeysAnnotation: "cluster1.ns"}),
+			old:     newTestCluster(),
+			err:     errors.New("connection refused"),
+			wantErr: true,
+		},
+		{
+			name: "bad allowlist",
+			c:    cluster("vault", map[string]string{transfer.AllowAnnotation: `{"allow": [{"nameRegex": "("}]}`}),
+			old:  newTestCluster(),
+			want: []string{"invalid " + transfer.AllowAnnotation + " annotation"},
+		},
+		{
+			name: "unchanged bad allowlist",
+			c:    cluster("vault", map[string]string{transfer.AllowAnnotation: `{"allow": [`}),
+			old:  cluster("vault", map[string]string{transfer.AllowAnnotation: `{"allow": [`}),
+		},
+		{
+			name: "bad move allowlist",
+			c:    cluster("vault", map[string]string{transfer.AllowMoveAnnotation: `{"allow": [`}),
+			old:  newTestCluster(),
+			want: []string{"invalid " + transfer.AllowMoveAnnotation + " annotation"},
+		},
+		{
+			name: "good allowlists",
+			c: cluster("vault", map[string]string{
+				transfer.AllowAnnotation:     "cluster1.ns,!cluster3.*",
+				transfer.AllowMoveAnnotation: `{"allow": [{"name": "cluster1", "namespace": "ns"}]}`,
+			}),
+			old: newTestCluster(),
+		},
+		{
+			name: "bad approval expiry",
+			c:    cluster("vault", map[string]string{transfer.AllowExpiresAnnotation: "tomorrow"}),
+			old:  newTestCluster(),
+			want: []string{"parse " + transfer.AllowExpiresAnnotation + " annotation"},
+		},
+		{
+			name: "bad approval uses",
+			c:    cluster("vault", map[string]string{transfer.AllowUsesAnnotation: "-1"}),
+			old:  newTestCluster(),
+			want: []string{"must be a non-negative number"},
+		},
+		{
+			name: "good approval",
+			c: cluster("vault", map[string]string{
+				transfer.AllowExpiresAnnotation: "2030-01-01T00:00:00Z",
+				transfer.AllowUsesAnnotation:    "2",
+			}),
+			old: newTestCluster(),
+		},
+		{
+			name: "bad conflict policy",
+			c:    cluster("vault", map[string]string{TransferConflictAnnotation: "Merge"}),
+			old:  newTestCluster(),
+			want: []string{"invalid " + TransferConflictAnnotation + " annotation"},
+		},
+		{
+			name: "bad token ttl",
+			c:    cluster("vault", map[string]string{TokenTTLAnnotation: "1 day"}),
+			old:  newTestCluster(),
+			want: []string{"parse " + TokenTTLAnnotation + " annotation"},
+		},
+		{
+			name: "several problems",
+			c: cluster("", map[string]string{
+				IssueAnnotation:            "true",
+				TransferConflictAnnotation: "Merge",
+			}),
+			old:  newTestCluster(),
+			want: []string{"data.secret must be set", "invalid " + TransferConflictAnnotation + " annotation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{
+				Client: fakeClient{
+					clusters: map[client.ObjectKey]bool{{Namespace: "ns", Name: "cluster1"}: true},
+					err:      tt.err,
+				},
+				NewCluster: newTestCluster,
+			}
+
+			got, err := v.validate(context.TODO(), tt.c, tt.old)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("validate() = %q, want problems matching %q", got, tt.want)
+			}
+			for i := range got {
+				if !strings.Contains(got[i], tt.want[i]) {
+					t.Errorf("validate()[%d] = %q, want it to contain %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package keyringconf parses and renders keyring_vault.conf files used by
+// the keyring_vault plugin of Percona XtraDB Cluster.
+//
+// The file consists of "key = value" lines. Blank lines and lines starting
+// with "#" are kept as is, as well as spacing, quoting and unknown keys, so
+// rendering a parsed file gives back the same bytes.
+package keyringconf
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Keys known to keyring_vault.
+const (
+	KeyToken                   = "token"
+	KeyVaultURL                = "vault_url"
+	KeySecretMountPoint        = "secret_mount_point"
+	KeySecretMountPointVersion = "secret_mount_point_version"
+	KeyVaultCA                 = "vault_ca"
+)
+
+// DefaultCAPath is where Percona XtraDB Cluster mounts ca.cert of the Vault secret.
+const DefaultCAPath = "/etc/mysql/vault-keyring-secret/ca.cert"
+
+// ParseError describes a line that can't be parsed.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Config is a parsed keyring_vault.conf.
+type Config struct {
+	lines []line
+}
+
+type line struct {
+	// raw is the line text, rendered as is for comments, blank lines and
+	// values that weren't changed
+	raw string
+	key string
+	// prefix is everything before the value: the key, "=" and spacing
+	prefix string
+	value  string
+	suffix string
+	// quote is the character the value was quoted with, if any
+	quote    byte
+	modified bool
+}
+
+// New returns an empty config.
+func New() *Config {
+	return &Config{}
+}
+
+// Parse parses keyring_vault.conf content.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	seen := make(map[string]int)
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		l := line{raw: raw}
+
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			c.lines = append(c.lines, l)
+			continue
+		}
+
+		eq := strings.Index(raw, "=")
+		if eq < 0 {
+			return nil, &ParseError{Line: i + 1, Msg: "expected key = value"}
+		}
+
+		l.key = strings.TrimSpace(raw[:eq])
+		if l.key == "" {
+			return nil, &ParseError{Line: i + 1, Msg: "empty key"}
+		}
+		if prev, ok := seen[l.key]; ok {
+			return nil, &ParseError{Line: i + 1, Msg: fmt.Sprintf("duplicate key %q, first defined on line %d", l.key, prev)}
+		}
+		seen[l.key] = i + 1
+
+		rest := raw[eq+1:]
+		value := strings.TrimSpace(rest)
+		start := len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace))
+		l.prefix = raw[:eq+1] + rest[:start]
+		l.suffix = rest[start+len(value):]
+
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			l.quote = value[0]
+			value = value[1 : n-1]
+		}
+		l.value = value
+
+		c.lines = append(c.lines, l)
+	}
+
+	return c, nil
+}
+
+// Get returns unquoted value of the key.
+func (c *Config) Get(key string) (string, bool) {
+	for _, l := range c.lines {
+		if l.key != "" && l.key == key {
+			return l.value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes value of the key keeping its spacing and quoting,
+// or appends a new "key = value" line.
+func (c *Config) Set(key, value string) {
+	for i := range c.lines {
+		if c.lines[i].key == key {
+			c.lines[i].value = value
+			c.lines[i].modified = true
+			return
+		}
+	}
+
+	l := line{
+		key:      key,
+		prefix:   key + " = ",
+		value:    value,
+		modified: true,
+	}
+
+	// keep the trailing newline at the end of the file
+	if n := len(c.lines); n > 0 && c.lines[n-1].key == "" && c.lines[n-1].raw == "" {
+		c.lines = append(c.lines[:n-1], l, c.lines[n-1])
+		return
+	}
+	c.lines = append(c.lines, l)
+}
+
+// Delete removes the key.
+func (c *Config) Delete(key string) {
+	for i := range c.lines {
+		if c.lines[i].key == key {
+			c.lines = append(c.lines[:i], c.lines[i+1:]...)
+			return
+		}
+	}
+}
+
+// Bytes renders the config.
+func (c *Config) Bytes() []byte {
+	out := make([]string, 0, len(c.lines))
+	for _, l := range c.lines {
+		if l.key == "" || !l.modified {
+			out = append(out, l.raw)
+			continue
+		}
+
+		value := l.value
+		if l.quote != 0 {
+			value = string(l.quote) + value + string(l.quote)
+		}
+		out = append(out, l.prefix+value+l.suffix)
+	}
+
+	return []byte(strings.Join(out, "\n"))
+}
+
+// Validate checks that the keys required to reach Vault are set and valid.
+func (c *Config) Validate() error {
+	u, ok := c.Get(KeyVaultURL)
+	if !ok || u == "" {
+		return errors.Errorf("%s is not set", KeyVaultURL)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s", KeyVaultURL)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.Errorf("invalid %s %q: expected http(s)://host[:port]", KeyVaultURL, u)
+	}
+
+	if mp, ok := c.Get(KeySecretMountPoint); !ok || mp == "" {
+		return errors.Errorf("%s is not set", KeySecretMountPoint)
+	}
+
+	if v, ok := c.Get(KeySecretMountPointVersion); ok {
+		switch v {
+		case "AUTO", "1", "2":
+		default:
+			return errors.Errorf("invalid %s %q: expected AUTO, 1 or 2", KeySecretMountPointVersion, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package keyringconf
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "plain",
+			in:   "token = s.abc\nvault_url = https://vault:8200\nsecret_mount_point = secret/ns/name",
+			want: map[string]string{
+				KeyToken:            "s.abc",
+				KeyVaultURL:         "https://vault:8200",
+				KeySecretMountPoint: "secret/ns/name",
+			},
+		},
+		{
+			name: "comments and blank lines",
+			in:   "\ntoken = s.abc\n\n  # comment = ignored\n#vault_ca = /etc/mysql/vault-keyring-secret/ca.cert\n",
+			want: map[string]string{KeyToken: "s.abc"},
+		},
+		{
+			name: "value with equal sign",
+			in:   "token=a=b==",
+			want: map[string]string{KeyToken: "a=b=="},
+		},
+		{
+			name: "quoted values",
+			in:   "token = \"s.abc\"\nsecret_mount_point='secret'",
+			want: map[string]string{KeyToken: "s.abc", KeySecretMountPoint: "secret"},
+		},
+		{
+			name: "unknown and empty keys",
+			in:   "foo = bar\nvault_ca =",
+			want: map[string]string{"foo": "bar", KeyVaultCA: ""},
+		},
+		{
+			name:    "missing equal sign",
+			in:      "token = s.abc\nvault_url",
+			wantErr: "line 2: expected key = value",
+		},
+		{
+			name:    "empty key",
+			in:      " = value",
+			wantErr: "line 1: empty key",
+		},
+		{
+			name:    "duplicate key",
+			in:      "token = a\ntoken = b",
+			wantErr: `line 2: duplicate key "token", first defined on line 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for k, v := range tt.want {
+				got, ok := c.Get(k)
+				if !ok || got != v {
+					t.Errorf("%s: expected %q, got %q (found: %v)", k, v, got, ok)
+				}
+			}
+
+			if got := string(c.Bytes()); got != tt.in {
+				t.Errorf("rendered config differs from input:\n%q\n%q", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "keeps spacing",
+			in:    "token   =  old  \nvault_url = https://vault:8200",
+			key:   KeyToken,
+			value: "new",
+			want:  "token   =  new  \nvault_url = https://vault:8200",
+		},
+		{
+			name:  "keeps quoting",
+			in:    "token = \"old\"",
+			key:   KeyToken,
+			value: "new",
+			want:  "token = \"new\"",
+		},
+		{
+			name:  "appends before trailing newline",
+			in:    "# issued\nvault_url = https://vault:8200\n",
+			key:   KeyToken,
+			value: "new",
+			want:  "# issued\nvault_url = https://vault:8200\ntoken = new\n",
+		},
+		{
+			name:  "empty config",
+			in:    "",
+			key:   KeyToken,
+			value: "new",
+			want:  "token = new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			c.Set(tt.key, tt.value)
+			if got := string(c.Bytes()); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			in:   "vault_url = https://vault:8200\nsecret_mount_point = secret\nsecret_mount_point_version = 2",
+		},
+		{
+			name:    "no url",
+			in:      "secret_mount_point = secret",
+			wantErr: "vault_url is not set",
+		},
+		{
+			name:    "url without scheme",
+			in:      "vault_url = vault:8200\nsecret_mount_point = secret",
+			wantErr: `invalid vault_url "vault:8200": expected http(s)://host[:port]`,
+		},
+		{
+			name:    "no mount point",
+			in:      "vault_url = http://vault:8200",
+			wantErr: "secret_mount_point is not set",
+		},
+		{
+			name:    "invalid version",
+			in:      "vault_url = http://vault:8200\nsecret_mount_point = secret\nsecret_mount_point_version = 3",
+			wantErr: `invalid secret_mount_point_version "3": expected AUTO, 1 or 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
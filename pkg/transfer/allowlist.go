@@ -0,0 +1,174 @@
+package transfer
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Allowlist decides which clusters may copy keys of a source cluster.
+// A cluster is allowed if any of the Allow rules and none of the Deny
+// rules match it.
+type Allowlist struct {
+	Allow []Rule `json:"allow,omitempty"`
+	Deny  []Rule `json:"deny,omitempty"`
+}
+
+// Rule matches clusters, all of its set fields must match.
+type Rule struct {
+	// Name and Namespace are glob patterns, see path.Match.
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// NameRegex is a regular expression the whole name must match.
+	NameRegex string `json:"nameRegex,omitempty"`
+	// Selector matches labels of the cluster.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// NamespaceSelector matches labels of the cluster's namespace.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	nameRegex         *regexp.Regexp
+	selector          labels.Selector
+	namespaceSelector labels.Selector
+}
+
+// Requester is the cluster asking for keys.
+type Requester struct {
+	Cluster
+	Labels map[string]string
+	// NamespaceLabels are required only if the allowlist has namespace
+	// selectors, see NamespaceSelected.
+	NamespaceLabels map[string]string
+}
+
+// ParseAllowlist parses the value of AllowAnnotation. It is either a
+// comma separated list of name.namespace glob patterns, "*" allows every
+// cluster and patterns starting with "!" deny clusters, e.g.
+// "*.team-a,!legacy.team-a", or a JSON Allowlist.
+func ParseAllowlist(s string) (Allowlist, error) {
+	al := Allowlist{}
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&al)
+		if err != nil {
+			return al, errors.Wrap(err, "parse allowlist")
+		}
+	} else {
+		for _, v := range strings.Split(s, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+
+			deny := strings.HasPrefix(v, "!")
+			v = strings.TrimPrefix(v, "!")
+
+			r := Rule{Name: "*", Namespace: "*"}
+			if v != "*" {
+				c, err := ParseCluster(v)
+				if err != nil {
+					return al, err
+				}
+				r.Name, r.Namespace = c.Name, c.Namespace
+			}
+
+			if deny {
+				al.Deny = append(al.Deny, r)
+			} else {
+				al.Allow = append(al.Allow, r)
+			}
+		}
+	}
+
+	for _, rules := range [][]Rule{al.Allow, al.Deny} {
+		for i := range rules {
+			err := rules[i].compile()
+			if err != nil {
+				return al, err
+			}
+		}
+	}
+
+	return al, nil
+}
+
+func (r *Rule) compile() error {
+	for _, p := range []string{r.Name, r.Namespace} {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid pattern %q", p)
+		}
+	}
+
+	var err error
+	if r.NameRegex != "" {
+		r.nameRegex, err = regexp.Compile("^(?:" + r.NameRegex + ")$")
+		if err != nil {
+			return errors.Wrapf(err, "invalid name regex %q", r.NameRegex)
+		}
+	}
+	if r.Selector != nil {
+		r.selector, err = metav1.LabelSelectorAsSelector(r.Selector)
+		if err != nil {
+			return errors.Wrap(err, "invalid selector")
+		}
+	}
+	if r.NamespaceSelector != nil {
+		r.namespaceSelector, err = metav1.LabelSelectorAsSelector(r.NamespaceSelector)
+		if err != nil {
+			return errors.Wrap(err, "invalid namespace selector")
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether the requester may copy keys.
+func (al Allowlist) Allows(r Requester) bool {
+	for _, rule := range al.Deny {
+		if rule.matches(r) {
+			return false
+		}
+	}
+	for _, rule := range al.Allow {
+		if rule.matches(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceSelected is true if namespace labels of requesters are needed.
+func (al Allowlist) NamespaceSelected() bool {
+	for _, rules := range [][]Rule{al.Allow, al.Deny} {
+		for _, r := range rules {
+			if r.NamespaceSelector != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r Rule) matches(req Requester) bool {
+	if ok, _ := path.Match(r.Name, req.Name); r.Name != "" && !ok {
+		return false
+	}
+	if ok, _ := path.Match(r.Namespace, req.Namespace); r.Namespace != "" && !ok {
+		return false
+	}
+	if r.nameRegex != nil && !r.nameRegex.MatchString(req.Name) {
+		return false
+	}
+	if r.selector != nil && !r.selector.Matches(labels.Set(req.Labels)) {
+		return false
+	}
+	if r.namespaceSelector != nil && !r.namespaceSelector.Matches(labels.Set(req.NamespaceLabels)) {
+		return false
+	}
+	return true
+}
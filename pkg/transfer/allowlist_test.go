@@ -0,0 +1,110 @@
+package transfer
+
+import (
+	"testing"
+)
+
+func TestParseCluster(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Cluster
+		wantErr bool
+	}{
+		{in: "cluster1.team-a", want: Cluster{Name: "cluster1", Namespace: "team-a"}},
+		{in: "cluster.v2.team-a", want: Cluster{Name: "cluster.v2", Namespace: "team-a"}},
+		{in: "cluster1", wantErr: true},
+		{in: ".team-a", wantErr: true},
+		{in: "cluster1.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseCluster(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCluster() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	prod := Requester{
+		Cluster:         Cluster{Name: "prod-1", Namespace: "team-a"},
+		Labels:          map[string]string{"env": "prod"},
+		NamespaceLabels: map[string]string{"team": "a"},
+	}
+	dotted := Requester{Cluster: Cluster{Name: "cluster.v2", Namespace: "team-b"}}
+
+	tests := []struct {
+		name      string
+		allowlist string
+		requester Requester
+		want      bool
+		wantErr   bool
+	}{
+		{name: "all", allowlist: "*", requester: prod, want: true},
+		{name: "exact", allowlist: "other.team-a, prod-1.team-a", requester: prod, want: true},
+		{name: "not listed", allowlist: "other.team-a", requester: prod},
+		{name: "namespace wildcard", allowlist: "*.team-a", requester: prod, want: true},
+		{name: "name glob", allowlist: "prod-*.team-?", requester: prod, want: true},
+		{name: "denied", allowlist: "*.team-a,!prod-*.team-a", requester: prod},
+		{name: "dotted name", allowlist: "cluster.v2.team-b", requester: dotted, want: true},
+		{name: "dotted name other namespace", allowlist: "cluster.v2.team-a", requester: dotted},
+		{name: "empty", allowlist: "", requester: prod},
+		{name: "invalid entry", allowlist: "cluster1", wantErr: true},
+		{name: "invalid glob", allowlist: "[.team-a", wantErr: true},
+		{
+			name:      "regex",
+			allowlist: `{"allow": [{"nameRegex": "prod-[0-9]+", "namespace": "team-a"}]}`,
+			requester: prod,
+			want:      true,
+		},
+		{
+			name:      "regex matches whole name",
+			allowlist: `{"allow": [{"nameRegex": "prod"}]}`,
+			requester: prod,
+		},
+		{
+			name:      "selectors",
+			allowlist: `{"allow": [{"selector": {"matchLabels": {"env": "prod"}}, "namespaceSelector": {"matchLabels": {"team": "a"}}}]}`,
+			requester: prod,
+			want:      true,
+		},
+		{
+			name:      "selector mismatch",
+			allowlist: `{"allow": [{"selector": {"matchLabels": {"env": "dev"}}}]}`,
+			requester: prod,
+		},
+		{
+			name:      "structured deny",
+			allowlist: `{"allow": [{}], "deny": [{"namespaceSelector": {"matchExpressions": [{"key": "team", "operator": "In", "values": ["a"]}]}}]}`,
+			requester: prod,
+		},
+		{
+			name:      "structured dotted name",
+			allowlist: `{"allow": [{"name": "cluster.v2", "namespace": "team-b"}]}`,
+			requester: dotted,
+			want:      true,
+		},
+		{name: "unknown field", allowlist: `{"allow": [{"cluster": "prod-1"}]}`, wantErr: true},
+		{name: "invalid regex", allowlist: `{"allow": [{"nameRegex": "("}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			al, err := ParseAllowlist(tt.allowlist)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAllowlist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := al.Allows(tt.requester); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
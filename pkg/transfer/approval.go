@@ -0,0 +1,64 @@
+package transfer
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Annotations limiting the approval given by AllowAnnotation, both are
+// optional and the approval is permanent without them.
+const (
+	// AllowExpiresAnnotation is the RFC 3339 time the approval expires at.
+	AllowExpiresAnnotation = "percona.com/allow-transition-key-transfer-expires"
+	// AllowUsesAnnotation is the number of transfers left, it is
+	// decremented by every successful transfer.
+	AllowUsesAnnotation = "percona.com/allow-transition-key-transfer-uses"
+)
+
+// ApprovalAnnotations are removed from the source cluster once its approval
+// expires or is used up.
+var ApprovalAnnotations = []string{AllowAnnotation, AllowMoveAnnotation, AllowExpiresAnnotation, AllowUsesAnnotation}
+
+// Approval limits of a source cluster.
+type Approval struct {
+	// Expires is zero if the approval doesn't expire.
+	Expires time.Time
+	// Uses is negative if the number of uses isn't limited.
+	Uses int
+}
+
+// ParseApproval reads the limits from annotations of the source cluster.
+func ParseApproval(annotations map[string]string) (Approval, error) {
+	a := Approval{Uses: -1}
+
+	if v, ok := annotations[AllowExpiresAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return a, errors.Wrapf(err, "parse %s annotation", AllowExpiresAnnotation)
+		}
+		a.Expires = t
+	}
+
+	if v, ok := annotations[AllowUsesAnnotation]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return a, errors.Errorf("invalid %s annotation %q, it must be a non-negative number", AllowUsesAnnotation, v)
+		}
+		a.Uses = n
+	}
+
+	return a, nil
+}
+
+// Check fails if the approval is expired or used up at the time.
+func (a Approval) Check(now time.Time) error {
+	if !a.Expires.IsZero() && !now.Before(a.Expires) {
+		return errors.Errorf("approval expired at %s", a.Expires.Format(time.RFC3339))
+	}
+	if a.Uses == 0 {
+		return errors.New("approval is used up")
+	}
+	return nil
+}
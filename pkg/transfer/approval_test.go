@@ -0,0 +1,40 @@
+package transfer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApproval(t *testing.T) {
+	now := time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+		wantDenied  bool
+	}{
+		{name: "permanent", annotations: map[string]string{}},
+		{name: "not expired", annotations: map[string]string{AllowExpiresAnnotation: "2020-10-16T00:00:00Z"}},
+		{name: "expired", annotations: map[string]string{AllowExpiresAnnotation: "2020-10-15T12:00:00Z"}, wantDenied: true},
+		{name: "uses left", annotations: map[string]string{AllowUsesAnnotation: "1"}},
+		{name: "used up", annotations: map[string]string{AllowUsesAnnotation: "0"}, wantDenied: true},
+		{name: "invalid time", annotations: map[string]string{AllowExpiresAnnotation: "tomorrow"}, wantErr: true},
+		{name: "negative uses", annotations: map[string]string{AllowUsesAnnotation: "-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ParseApproval(tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := a.Check(now); (err != nil) != tt.wantDenied {
+				t.Errorf("Check() error = %v, wantDenied %v", err, tt.wantDenied)
+			}
+		})
+	}
+}
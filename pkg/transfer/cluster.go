@@ -0,0 +1,38 @@
+package transfer
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AllowAnnotation is set on the source cluster to allow other clusters
+// to copy its transition keys, its value is an allowlist, see ParseAllowlist.
+const AllowAnnotation = "percona.com/allow-transition-key-transfer"
+
+// AllowMoveAnnotation is set on the source cluster alongside AllowAnnotation
+// to allow clusters to move its transition keys, deleting them from the
+// source. Its value has the format of AllowAnnotation.
+const AllowMoveAnnotation = "percona.com/allow-transition-key-move"
+
+// Cluster identifies a database cluster, it is written as name.namespace
+// in annotations.
+type Cluster struct {
+	Name      string
+	Namespace string
+}
+
+func (c Cluster) String() string {
+	return c.Name + "." + c.Namespace
+}
+
+// ParseCluster parses name.namespace. Namespaces can't contain dots, so
+// the name is everything before the last one.
+func ParseCluster(s string) (Cluster, error) {
+	i := strings.LastIndex(s, ".")
+	if i <= 0 || i == len(s)-1 {
+		return Cluster{}, errors.Errorf("invalid cluster %q, please use format clusterName.namespace", s)
+	}
+
+	return Cluster{Name: s[:i], Namespace: s[i+1:]}, nil
+}
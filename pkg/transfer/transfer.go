@@ -0,0 +1,408 @@
+// Package transfer copies transition keys of backups between the Vault
+// paths of database clusters.
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// Result of a single key transfer.
+type Result string
+
+const (
+	ResultCopied  Result = "Copied"
+	ResultSkipped Result = "Skipped"
+	ResultFailed  Result = "Failed"
+	// ResultPlanned is reported by dry runs for keys that would be copied.
+	ResultPlanned Result = "Planned"
+	// ResultMoved is reported for keys copied and deleted from the source.
+	ResultMoved Result = "Moved"
+)
+
+// ConflictPolicy defines what happens if the key already exists at
+// the destination.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing key.
+	ConflictOverwrite ConflictPolicy = "Overwrite"
+	// ConflictSkipExisting keeps the existing key.
+	ConflictSkipExisting ConflictPolicy = "SkipExisting"
+	// ConflictFailOnDifference keeps the existing key, the key is skipped if
+	// it is identical and failed otherwise.
+	ConflictFailOnDifference ConflictPolicy = "FailOnDifference"
+)
+
+// ParseConflictPolicy returns the policy, empty for the default one of
+// the keys, see Options.Conflict.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case "", ConflictOverwrite, ConflictSkipExisting, ConflictFailOnDifference:
+		return p, nil
+	default:
+		return "", errors.Errorf("unknown conflict policy %q", s)
+	}
+}
+
+// KeyResult describes what happened to a key.
+type KeyResult struct {
+	// Key is the backup UID of a transition key or the path of a master key
+	// relative to the cluster's path.
+	Key     string
+	Result  Result
+	Message string
+	// Checksum of the current version of the key, see Checksum. Copied keys
+	// are read back from the destination and verified to match it.
+	Checksum string
+}
+
+// Options of a transfer.
+type Options struct {
+	// BackupUIDs limits the transfer to keys of the backups, all keys are
+	// copied if empty.
+	BackupUIDs []string
+	// Conflict is ConflictOverwrite for transition keys and
+	// ConflictSkipExisting for master keys if empty.
+	Conflict ConflictPolicy
+	// AllVersions copies every live version of keys in a KV version 2
+	// engine, oldest first, rather than only the current one.
+	AllVersions bool
+	// BatchSize is the number of keys copied between progress reports,
+	// DefaultBatchSize if zero.
+	BatchSize int
+	// Concurrency is the number of keys copied at once, DefaultConcurrency
+	// if zero.
+	Concurrency int
+	// Progress is called after every batch with the number of processed
+	// and all keys, optional.
+	Progress func(done, total int)
+	// DryRun reads and compares keys but doesn't write anything, keys that
+	// would be copied are reported as ResultPlanned.
+	DryRun bool
+	// Move deletes keys from the source once the destination is verified to
+	// hold them, keys are reported as ResultMoved then.
+	Move bool
+}
+
+const (
+	DefaultBatchSize   = 100
+	DefaultConcurrency = 4
+)
+
+// Location is a cluster's path in Vault.
+type Location struct {
+	Client *api.Client
+	KV     vault.KV
+	// Path is the logical path of the cluster, see vault.KV.
+	Path string
+}
+
+func (l Location) key(rel string) string {
+	return l.Path + "/" + rel
+}
+
+// TransitionKeys copies transition keys stored below <from>/backup/ to
+// <to>/backup/, keys are named by their path relative to backup/.
+// Failures of single keys are reported in the results, the error is
+// returned only if keys can't be listed.
+func TransitionKeys(from, to Location, opts Options) ([]KeyResult, error) {
+	if opts.Move && from.KV.Version == 2 && !opts.AllVersions {
+		// deleting a key drops all its versions
+		return nil, errors.New("keys of kv version 2 can be moved only with all versions")
+	}
+
+	keys, err := listTree(from, "backup/")
+	if err != nil {
+		return nil, errors.Wrap(err, "list transition keys")
+	}
+	for i := range keys {
+		keys[i] = strings.TrimPrefix(keys[i], "backup/")
+	}
+
+	if len(opts.BackupUIDs) == 0 {
+		if len(keys) == 0 {
+			return nil, errors.New("no transition keys found")
+		}
+		return copyKeys(from, to, "backup/", keys, opts), nil
+	}
+
+	// a backup may keep its keys in a folder named by its UID
+	selected := make([]string, 0, len(opts.BackupUIDs))
+	missing := make([]KeyResult, 0)
+	seen := make(map[string]bool)
+	for _, uid := range opts.BackupUIDs {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		found := false
+		for _, key := range keys {
+			if key != uid && !strings.HasPrefix(key, uid+"/") {
+				continue
+			}
+			found = true
+			if !seen[key] {
+				selected = append(selected, key)
+				seen[key] = true
+			}
+		}
+		if !found {
+			missing = append(missing, KeyResult{Key: uid, Result: ResultFailed, Message: "transition key not found"})
+		}
+	}
+
+	return append(copyKeys(from, to, "backup/", selected, opts), missing...), nil
+}
+
+// copyKeys copies the keys, paths relative to the locations are prefixed
+// with the prefix. Keys are copied in batches by opts.Concurrency workers,
+// results are in the order of keys.
+func copyKeys(from, to Location, prefix string, keys []string, opts Options) []KeyResult {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]KeyResult, len(keys))
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		idx := make(chan int)
+		wg := sync.WaitGroup{}
+		for w := 0; w < concurrency && w < end-start; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range idx {
+					results[i] = copyKey(from, to, prefix+keys[i], keys[i], opts)
+				}
+			}()
+		}
+		for i := start; i < end; i++ {
+			idx <- i
+		}
+		close(idx)
+		wg.Wait()
+
+		if opts.Progress != nil {
+			opts.Progress(end, len(keys))
+		}
+	}
+
+	return results
+}
+
+// copyKey copies the secret at the path relative to the locations. Metadata
+// settings of KV version 2 secrets are copied before the data, so
+// max_versions applies to the copied versions as well. The data is written
+// with check-and-set, as cas_required may be set on either side.
+func copyKey(from, to Location, rel, key string, opts Options) KeyResult {
+	res := KeyResult{Key: key}
+	fail := func(err error, msg string) KeyResult {
+		res.Result = ResultFailed
+		res.Message = errors.Wrap(err, msg).Error()
+		return res
+	}
+
+	var existing map[string]interface{}
+	if opts.Conflict == ConflictSkipExisting || opts.Conflict == ConflictFailOnDifference || opts.DryRun {
+		var err error
+		existing, err = to.KV.Read(to.Client.Logical(), to.key(rel))
+		if err != nil {
+			return fail(err, "read destination key")
+		}
+		if existing != nil && opts.Conflict == ConflictSkipExisting {
+			res.Result = ResultSkipped
+			res.Message = "key already exists, the destination's key is kept"
+			return res
+		}
+	}
+
+	md, err := from.KV.ReadMetadata(from.Client.Logical(), from.key(rel))
+	if err != nil {
+		return fail(err, "read key metadata")
+	}
+
+	versions := []int{0}
+	if opts.AllVersions && md != nil && len(md.Versions) > 0 {
+		versions = md.Versions
+	}
+
+	data := make([]map[string]interface{}, 0, len(versions))
+	for _, v := range versions {
+		d, err := from.KV.ReadVersion(from.Client.Logical(), from.key(rel), v)
+		if err != nil {
+			return fail(err, "read secret data from vault")
+		}
+		if d != nil {
+			data = append(data, d)
+		}
+	}
+	if len(data) == 0 {
+		res.Result = ResultFailed
+		res.Message = "key not found"
+		return res
+	}
+	// the current version is the last one
+	res.Checksum, err = Checksum(data[len(data)-1])
+	if err != nil {
+		return fail(err, "checksum key")
+	}
+
+	if existing != nil && opts.Conflict == ConflictFailOnDifference {
+		equal, err := Equal(existing, data[len(data)-1])
+		if err != nil {
+			return fail(err, "compare keys")
+		}
+		if !equal {
+			res.Result = ResultFailed
+			res.Message = "key differs from the existing one"
+			return res
+		}
+		res.Result = ResultSkipped
+		res.Message = "identical key already exists"
+		if opts.Move && len(data) > 1 {
+			res.Message += ", source key kept as its previous versions aren't copied"
+			return res
+		}
+		if opts.Move {
+			return moveKey(from, rel, res, opts)
+		}
+		return res
+	}
+
+	if opts.DryRun {
+		capability := "create"
+		if existing != nil {
+			capability = "update"
+		}
+		err = vault.CheckCapabilities(to.Client, to.KV.DataPath(to.key(rel)), capability)
+		if err != nil {
+			return fail(err, "check destination access")
+		}
+
+		res.Result = ResultPlanned
+		res.Message = fmt.Sprintf("would copy %d version(s) to %s", len(data), to.KV.DataPath(to.key(rel)))
+		if existing != nil {
+			res.Message = fmt.Sprintf("would overwrite %s with %d version(s)", to.KV.DataPath(to.key(rel)), len(data))
+		}
+		if opts.Move {
+			return moveKey(from, rel, res, opts)
+		}
+		return res
+	}
+
+	dst, err := to.KV.ReadMetadata(to.Client.Logical(), to.key(rel))
+	if err != nil {
+		return fail(err, "read destination key metadata")
+	}
+	cas := 0
+	if dst != nil {
+		cas = dst.CurrentVersion
+	}
+
+	err = to.KV.WriteMetadata(to.Client.Logical(), to.key(rel), md)
+	if err != nil {
+		return fail(err, "copy key metadata")
+	}
+	for _, d := range data {
+		err = to.KV.WriteCAS(to.Client.Logical(), to.key(rel), d, cas)
+		if err != nil {
+			return fail(err, "copy key")
+		}
+		cas++
+	}
+
+	written, err := to.KV.Read(to.Client.Logical(), to.key(rel))
+	if err != nil {
+		return fail(err, "read back destination key")
+	}
+	sum, err := Checksum(written)
+	if err != nil {
+		return fail(err, "checksum destination key")
+	}
+	if sum != res.Checksum {
+		res.Result = ResultFailed
+		res.Message = fmt.Sprintf("destination key checksum %s doesn't match the source", sum)
+		return res
+	}
+
+	res.Result = ResultCopied
+	if opts.Move {
+		return moveKey(from, rel, res, opts)
+	}
+	return res
+}
+
+// moveKey deletes the source key the destination holds, a dry run checks
+// that it can be deleted.
+func moveKey(from Location, rel string, res KeyResult, opts Options) KeyResult {
+	path := from.KV.DeletePath(from.key(rel))
+	if opts.DryRun {
+		err := vault.CheckCapabilities(from.Client, path, "delete")
+		if err != nil {
+			res.Result = ResultFailed
+			res.Message = errors.Wrap(err, "check source access").Error()
+			return res
+		}
+		res.Message += fmt.Sprintf(", would delete %s", path)
+		return res
+	}
+
+	err := from.KV.Delete(from.Client.Logical(), from.key(rel))
+	if err != nil {
+		res.Result = ResultFailed
+		res.Message = errors.Wrap(err, "delete source key").Error()
+		return res
+	}
+
+	res.Result = ResultMoved
+	if res.Message != "" {
+		res.Message += ", source key deleted"
+	}
+	return res
+}
+
+// Equal compares secrets by their checksums.
+func Equal(a, b map[string]interface{}) (bool, error) {
+	sa, err := Checksum(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := Checksum(b)
+	if err != nil {
+		return false, err
+	}
+
+	return sa == sb, nil
+}
+
+// Checksum is the SHA-256 of the secret's canonical JSON encoding, which
+// has map keys sorted, as "sha256:<hex>".
+func Checksum(data map[string]interface{}) (string, error) {
+	j, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(j)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,114 @@
+package transfer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]interface{}
+		want bool
+	}{
+		{
+			name: "identical",
+			a:    map[string]interface{}{"key": "abc", "uid": "1"},
+			b:    map[string]interface{}{"uid": "1", "key": "abc"},
+			want: true,
+		},
+		{
+			name: "numbers",
+			a:    map[string]interface{}{"n": json.Number("1")},
+			b:    map[string]interface{}{"n": json.Number("1")},
+			want: true,
+		},
+		{
+			name: "different value",
+			a:    map[string]interface{}{"key": "abc"},
+			b:    map[string]interface{}{"key": "abd"},
+		},
+		{
+			name: "extra key",
+			a:    map[string]interface{}{"key": "abc"},
+			b:    map[string]interface{}{"key": "abc", "other": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Equal(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want string
+	}{
+		{
+			name: "key",
+			data: map[string]interface{}{"key": "abc"},
+			want: "sha256:ce6a544ca44df40624542d114f2b07855aa12f689ab89a4903d614b1d561aa18",
+		},
+		{
+			name: "empty",
+			data: map[string]interface{}{},
+			want: "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+		},
+		{
+			name: "missing",
+			want: "sha256:74234e98afe7498fb5daf1f36ac2d78acc339464f950703b8c019892f982b90b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Checksum(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Checksum() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	for in, want := range map[string]ConflictPolicy{
+		"":                 "",
+		"Overwrite":        ConflictOverwrite,
+		"SkipExisting":     ConflictSkipExisting,
+		"FailOnDifference": ConflictFailOnDifference,
+	} {
+		got, err := ParseConflictPolicy(in)
+		if err != nil || got != want {
+			t.Errorf("ParseConflictPolicy(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseConflictPolicy("skip"); err == nil {
+		t.Error("ParseConflictPolicy(\"skip\") returned no error")
+	}
+}
+
+func TestTransitionKeysMoveRequiresAllVersions(t *testing.T) {
+	from := Location{KV: vault.KV{Mount: "secret", Version: 2}, Path: "secret/ns/cluster1"}
+	to := Location{KV: vault.KV{Mount: "secret", Version: 2}, Path: "secret/ns/cluster2"}
+
+	_, err := TransitionKeys(from, to, Options{Move: true})
+	if err == nil {
+		t.Fatal("TransitionKeys() moved keys of kv version 2 without all versions")
+	}
+}
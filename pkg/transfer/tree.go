@@ -0,0 +1,64 @@
+package transfer
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MasterKeys copies every secret below the source path, e.g. master keys of
+// Percona Server for MongoDB replica sets, to the same relative path below
+// the destination path.
+func MasterKeys(from, to Location, opts Options) ([]KeyResult, error) {
+	if len(opts.BackupUIDs) > 0 {
+		return nil, errors.New("master keys can't be filtered by backup UIDs")
+	}
+	if opts.Move {
+		return nil, errors.New("master keys can't be moved")
+	}
+	// the destination's own master keys encrypt its data, so they are kept
+	// unless another policy is requested explicitly
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictSkipExisting
+	}
+
+	keys, err := listTree(from, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys found")
+	}
+
+	return copyKeys(from, to, "", keys, opts), nil
+}
+
+// listTree returns paths of all secrets below the prefix of the location
+// relative to the location. Folders end with a slash in list responses and
+// are walked into, so every returned path is a secret.
+func listTree(l Location, prefix string) ([]string, error) {
+	path := l.Path
+	if prefix != "" {
+		path = l.key(strings.TrimSuffix(prefix, "/"))
+	}
+	keys, err := l.KV.List(l.Client.Logical(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/") {
+			tree = append(tree, prefix+key)
+			continue
+		}
+
+		sub, err := listTree(l, prefix+key)
+		if err != nil {
+			return nil, err
+		}
+		tree = append(tree, sub...)
+	}
+
+	return tree, nil
+}
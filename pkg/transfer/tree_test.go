@@ -0,0 +1,122 @@
+package transfer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/vault"
+)
+
+// fakeVault serves secrets of a KV version 1 engine from memory.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+}
+
+func newFakeVault(t *testing.T, secrets map[string]map[string]interface{}) (*fakeVault, *api.Client) {
+	fv := &fakeVault{secrets: secrets}
+	srv := httptest.NewServer(fv)
+	t.Cleanup(srv.Close)
+
+	cl, err := api.NewClient(&api.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.SetToken("test")
+
+	return fv, cl
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+		path = strings.TrimSuffix(path, "/") + "/"
+		seen := make(map[string]bool)
+		keys := []string{}
+		for p := range fv.secrets {
+			if !strings.HasPrefix(p, path) {
+				continue
+			}
+			key := strings.TrimPrefix(p, path)
+			if i := strings.Index(key, "/"); i >= 0 {
+				key = key[:i+1]
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sort.Strings(keys)
+		fv.respond(w, map[string]interface{}{"keys": keys})
+	case r.Method == http.MethodGet:
+		data, ok := fv.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fv.respond(w, data)
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		data := make(map[string]interface{})
+		err := json.NewDecoder(r.Body).Decode(&data)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fv.secrets[path] = data
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(fv.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (fv *fakeVault) respond(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func TestMasterKeysKeepDestinationKeys(t *testing.T) {
+	fv, cl := newFakeVault(t, map[string]map[string]interface{}{
+		"secret/team-a/src/rs0": {"key": "source rs0"},
+		"secret/team-a/src/rs1": {"key": "source rs1"},
+		"secret/team-b/dst/rs0": {"key": "destination rs0"},
+	})
+	kv := vault.KV{Mount: "secret", Version: 1}
+	from := Location{Client: cl, KV: kv, Path: "secret/team-a/src"}
+	to := Location{Client: cl, KV: kv, Path: "secret/team-b/dst"}
+
+	results, err := MasterKeys(from, to, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Result{"rs0": ResultSkipped, "rs1": ResultCopied}
+	for _, res := range results {
+		if res.Result != want[res.Key] {
+			t.Errorf("key %s: result %s (%s), want %s", res.Key, res.Result, res.Message, want[res.Key])
+		}
+	}
+	if got := fv.secrets["secret/team-b/dst/rs0"]["key"]; got != "destination rs0" {
+		t.Errorf("destination rs0 = %v, want it kept", got)
+	}
+	if got := fv.secrets["secret/team-b/dst/rs1"]["key"]; got != "source rs1" {
+		t.Errorf("destination rs1 = %v, want it copied", got)
+	}
+}
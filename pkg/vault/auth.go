@@ -0,0 +1,160 @@
+package vault
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTokenPath = "/etc/k8s-vault-issuer/token"
+	// DefaultServiceAccountTokenPath is where the ServiceAccount JWT is mounted into pods.
+	DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// Authenticator provides the token used for the issuer's own Vault requests.
+type Authenticator interface {
+	Token(cl *api.Client, conf Conf) (string, error)
+}
+
+// StaticTokenAuth uses the token from VAULT_TOKEN_FILEPATH or, if the file
+// can't be read, the token from the root keyring_vault.conf.
+type StaticTokenAuth struct{}
+
+func (StaticTokenAuth) Token(_ *api.Client, conf Conf) (string, error) {
+	token, err := readVaultToken()
+	if err == nil {
+		return token, nil
+	}
+
+	if conf.Token == "" {
+		return "", errors.Wrap(err, "can't find vault token in secret and file")
+	}
+
+	return conf.Token, nil
+}
+
+// RootConfAuth uses credentials from the root keyring_vault.conf. If role_id
+// is set it logs in with the AppRole auth method, otherwise it falls back
+// to StaticTokenAuth.
+//
+// AppRole is configured by the following keys:
+//
+//	role_id        - role ID
+//	secret_id      - secret ID, or
+//	secret_id_file - path to the file with secret ID
+//	approle_path   - mount path of the auth method, "approle" if empty
+type RootConfAuth struct {
+	cache tokenCache
+}
+
+func (a *RootConfAuth) Token(cl *api.Client, conf Conf) (string, error) {
+	if conf.RoleID == "" {
+		return StaticTokenAuth{}.Token(cl, conf)
+	}
+
+	return a.cache.get(func() (*api.Secret, error) {
+		secretID := conf.SecretID
+		if conf.SecretIDFile != "" {
+			data, err := ioutil.ReadFile(conf.SecretIDFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "read secret_id file")
+			}
+			secretID = strings.TrimSpace(string(data))
+		}
+
+		mountPath := conf.AppRolePath
+		if mountPath == "" {
+			mountPath = "approle"
+		}
+
+		return cl.Logical().Write("auth/"+mountPath+"/login", map[string]interface{}{
+			"role_id":   conf.RoleID,
+			"secret_id": secretID,
+		})
+	})
+}
+
+// KubernetesAuth logs into Vault with the Kubernetes auth method using the
+// ServiceAccount JWT. The client token is cached and the issuer logs in
+// again once two thirds of its lease are over.
+type KubernetesAuth struct {
+	// MountPath of the auth method, "kubernetes" if empty.
+	MountPath string
+	Role      string
+	// JWTPath is DefaultServiceAccountTokenPath if empty.
+	JWTPath string
+
+	cache tokenCache
+}
+
+func (a *KubernetesAuth) Token(cl *api.Client, _ Conf) (string, error) {
+	return a.cache.get(func() (*api.Secret, error) {
+		jwtPath := a.JWTPath
+		if jwtPath == "" {
+			jwtPath = DefaultServiceAccountTokenPath
+		}
+
+		jwt, err := ioutil.ReadFile(jwtPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "read service account token")
+		}
+
+		mountPath := a.MountPath
+		if mountPath == "" {
+			mountPath = "kubernetes"
+		}
+
+		return cl.Logical().Write("auth/"+mountPath+"/login", map[string]interface{}{
+			"role": a.Role,
+			"jwt":  string(jwt),
+		})
+	})
+}
+
+type tokenCache struct {
+	mu      sync.Mutex
+	token   string
+	renewAt time.Time
+}
+
+func (c *tokenCache) get(login func() (*api.Secret, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.renewAt.IsZero() || time.Now().Before(c.renewAt)) {
+		return c.token, nil
+	}
+
+	sec, err := login()
+	if err != nil {
+		return "", err
+	}
+	if sec == nil || sec.Auth == nil {
+		return "", errors.New("no auth info in login response")
+	}
+
+	c.token = sec.Auth.ClientToken
+	c.renewAt = time.Time{}
+	if sec.Auth.LeaseDuration > 0 {
+		lease := time.Duration(sec.Auth.LeaseDuration) * time.Second
+		c.renewAt = time.Now().Add(lease * 2 / 3)
+	}
+
+	return c.token, nil
+}
+
+func readVaultToken() (string, error) {
+	path := defaultTokenPath
+	if envPath, ok := os.LookupEnv("VAULT_TOKEN_FILEPATH"); ok {
+		path = envPath
+	}
+
+	data, err := ioutil.ReadFile(path)
+	return string(data), err
+}
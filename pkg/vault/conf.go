@@ -0,0 +1,211 @@
+// Package vault contains the Vault plumbing shared by the issuer controllers:
+// configuration, clients, the issuer's own authentication, tokens and policies.
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Percona-Lab/k8s-vault-issuer/pkg/keyringconf"
+)
+
+// Conf is the Vault configuration read from keyring_vault.conf of a secret.
+type Conf struct {
+	Cert             []byte
+	URL              string
+	Token            string
+	SecretMountPoint string
+	RoleID           string
+	SecretID         string
+	SecretIDFile     string
+	AppRolePath      string
+}
+
+// ConfFrom reads keyring_vault.conf and ca.cert of the secret.
+func ConfFrom(c client.Client, namespace, secretName string) (Conf, error) {
+	rootSecretObj := corev1.Secret{}
+	err := c.Get(context.TODO(),
+		types.NamespacedName{
+			Namespace: namespace,
+			Name:      secretName,
+		},
+		&rootSecretObj,
+	)
+	if err != nil {
+		return Conf{}, errors.Wrap(err, "read secret")
+	}
+
+	vaultConf, ok := rootSecretObj.Data["keyring_vault.conf"]
+	if !ok {
+		return Conf{}, errors.New("can't find keyring_vault.conf in secret")
+	}
+	conf, err := keyringconf.Parse(vaultConf)
+	if err != nil {
+		return Conf{}, errors.Wrap(err, "parse keyring_vault.conf")
+	}
+	err = conf.Validate()
+	if err != nil {
+		return Conf{}, errors.Wrap(err, "invalid keyring_vault.conf")
+	}
+
+	get := func(key string) string {
+		v, _ := conf.Get(key)
+		return v
+	}
+
+	return Conf{
+		Cert:             rootSecretObj.Data["ca.cert"],
+		URL:              get(keyringconf.KeyVaultURL),
+		SecretMountPoint: get(keyringconf.KeySecretMountPoint),
+		Token:            get(keyringconf.KeyToken),
+		RoleID:           get("role_id"),
+		SecretID:         get("secret_id"),
+		SecretIDFile:     get("secret_id_file"),
+		AppRolePath:      get("approle_path"),
+	}, nil
+}
+
+// MongoDBConfFrom reads the Vault settings of Percona Server for MongoDB
+// from the secret: token, serverName, port, secret and ca.crt. The secret
+// path is returned as SecretMountPoint.
+func MongoDBConfFrom(c client.Client, namespace, secretName string) (Conf, error) {
+	secretObj := corev1.Secret{}
+	err := c.Get(context.TODO(),
+		types.NamespacedName{
+			Namespace: namespace,
+			Name:      secretName,
+		},
+		&secretObj,
+	)
+	if err != nil {
+		return Conf{}, errors.Wrap(err, "read secret")
+	}
+
+	for _, key := range []string{"serverName", "port", "secret"} {
+		if len(secretObj.Data[key]) == 0 {
+			return Conf{}, errors.Errorf("can't find %s in secret", key)
+		}
+	}
+
+	return Conf{
+		Cert:             secretObj.Data["ca.crt"],
+		URL:              "https://" + net.JoinHostPort(string(secretObj.Data["serverName"]), string(secretObj.Data["port"])),
+		Token:            string(secretObj.Data["token"]),
+		SecretMountPoint: string(secretObj.Data["secret"]),
+	}, nil
+}
+
+// SameServer reports whether both URLs point to the same Vault server,
+// ports default to the scheme's one. Schemes aren't compared as the
+// MongoDB secret has no scheme and https is assumed for it.
+func SameServer(a, b string) bool {
+	ua, erra := url.Parse(a)
+	ub, errb := url.Parse(b)
+	if erra != nil || errb != nil {
+		return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+	}
+
+	return strings.EqualFold(ua.Hostname(), ub.Hostname()) &&
+		port(ua) == port(ub)
+}
+
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if strings.EqualFold(u.Scheme, "http") {
+		return "80"
+	}
+	return "443"
+}
+
+// Root logs into Vault as the issuer, using the root secret from
+// the operator's namespace.
+type Root struct {
+	Client     client.Client
+	Namespace  string
+	SecretName string
+	// Auth provides the issuer's token, StaticTokenAuth if nil.
+	Auth Authenticator
+}
+
+// Login returns a client authenticated as the issuer and the root config.
+func (r *Root) Login() (*api.Client, Conf, error) {
+	rootVaultConf, err := ConfFrom(r.Client, r.Namespace, r.SecretName)
+	if err != nil {
+		return nil, Conf{}, errors.Wrap(err, "get root vault config")
+	}
+
+	cl, err := NewClient(rootVaultConf)
+	if err != nil {
+		return nil, Conf{}, errors.Wrap(err, "create vault client")
+	}
+
+	auth := r.Auth
+	if auth == nil {
+		auth = StaticTokenAuth{}
+	}
+
+	token, err := auth.Token(cl, rootVaultConf)
+	if err != nil {
+		return nil, Conf{}, errors.Wrap(err, "vault login")
+	}
+	cl.SetToken(token)
+
+	return cl, rootVaultConf, nil
+}
+
+// NewClient returns a client for conf.URL trusting conf.Cert, authenticated
+// with conf.Token.
+func NewClient(vaultConf Conf) (*api.Client, error) {
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	if vaultConf.Cert != nil {
+		tr, err := vaultTransport(vaultConf.Cert)
+		if err != nil {
+			return nil, errors.Wrap(err, "create vault transport")
+		}
+		httpClient.Transport = tr
+	}
+
+	client, err := api.NewClient(&api.Config{
+		HttpClient: httpClient,
+		Address:    vaultConf.URL,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create vault client")
+	}
+	client.SetToken(vaultConf.Token)
+	return client, nil
+}
+
+func vaultTransport(cert []byte) (http.RoundTripper, error) {
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get system cert pool")
+	}
+
+	ok := certPool.AppendCertsFromPEM(cert)
+	if !ok {
+		return nil, errors.New("failed to append cert")
+	}
+
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs: certPool,
+		},
+	}, nil
+}
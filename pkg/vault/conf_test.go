@@ -0,0 +1,26 @@
+package vault
+
+import (
+	"testing"
+)
+
+func TestSameServer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "https://vault:8200", b: "https://vault:8200", want: true},
+		{a: "https://vault:8200/", b: "https://VAULT:8200", want: true},
+		{a: "https://vault", b: "https://vault:443", want: true},
+		{a: "http://vault:8200", b: "https://vault:8200", want: true},
+		{a: "https://vault:8200", b: "https://vault-legacy:8200"},
+		{a: "https://vault:8200", b: "https://vault:8201"},
+		{a: "http://vault", b: "https://vault"},
+	}
+
+	for _, tt := range tests {
+		if got := SameServer(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameServer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+// DeleteTree deletes the path and everything below it.
+func DeleteTree(logical *api.Logical, path string) error {
+	list, err := logical.List(path + "/")
+	if err != nil {
+		return errors.Wrapf(err, "list %s", path)
+	}
+
+	if list != nil && list.Data != nil && list.Data["keys"] != nil {
+		for _, keyInterface := range list.Data["keys"].([]interface{}) {
+			key, ok := keyInterface.(string)
+			if !ok {
+				continue
+			}
+
+			if strings.HasSuffix(key, "/") {
+				err = DeleteTree(logical, path+"/"+strings.TrimSuffix(key, "/"))
+			} else {
+				_, err = logical.Delete(path + "/" + key)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "delete %s/%s", path, key)
+			}
+		}
+	}
+
+	_, err = logical.Delete(path)
+	return err
+}
+
+// KV is a key/value secrets engine mount. Paths passed to its methods are
+// logical paths "<mount>/<path>" as used by the version 1 engine, the
+// version 2 engine keeps them under "<mount>/data/" and "<mount>/metadata/".
+type KV struct {
+	// Mount is the mount path without the trailing slash.
+	Mount   string
+	Version int
+}
+
+// DetectKV finds the KV mount the path belongs to in sys/mounts. Tokens
+// that can't list mounts, e.g. tokens of clusters, look up the mount of
+// the path with sys/internal/ui/mounts which is allowed for paths
+// the token has access to.
+func DetectKV(cl *api.Client, path string) (KV, error) {
+	mounts, err := cl.Sys().ListMounts()
+	if err == nil {
+		return kvFromMounts(mounts, path)
+	}
+
+	sec, uerr := cl.Logical().Read("sys/internal/ui/mounts/" + path)
+	if uerr != nil || sec == nil || sec.Data == nil {
+		return KV{}, errors.Wrap(err, "list mounts")
+	}
+
+	mount := &api.MountOutput{}
+	mount.Type, _ = sec.Data["type"].(string)
+	mount.Options = make(map[string]string)
+	if opts, ok := sec.Data["options"].(map[string]interface{}); ok {
+		for k, v := range opts {
+			mount.Options[k], _ = v.(string)
+		}
+	}
+	mountPath, _ := sec.Data["path"].(string)
+
+	return kvFromMounts(map[string]*api.MountOutput{mountPath: mount}, path)
+}
+
+func kvFromMounts(mounts map[string]*api.MountOutput, path string) (KV, error) {
+	kv := KV{}
+	var mount *api.MountOutput
+	for p, m := range mounts {
+		p = strings.TrimSuffix(p, "/")
+		if path != p && !strings.HasPrefix(path, p+"/") {
+			continue
+		}
+		// the most specific mount wins
+		if mount == nil || len(p) > len(kv.Mount) {
+			kv.Mount, mount = p, m
+		}
+	}
+	if mount == nil {
+		return KV{}, errors.Errorf("no mount found for %s", path)
+	}
+
+	switch mount.Type {
+	case "kv", "generic":
+	default:
+		return KV{}, errors.Errorf("%s is mounted at %s, not kv", mount.Type, kv.Mount)
+	}
+
+	kv.Version = 1
+	if mount.Options["version"] == "2" {
+		kv.Version = 2
+	}
+
+	return kv, nil
+}
+
+func (kv KV) relative(path string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(path, kv.Mount), "/")
+}
+
+func (kv KV) join(prefix, path string) string {
+	rel := kv.relative(path)
+	if rel == "" {
+		return kv.Mount + "/" + prefix
+	}
+	return kv.Mount + "/" + prefix + "/" + rel
+}
+
+// DataPath returns the API path to read and write the secret.
+func (kv KV) DataPath(path string) string {
+	if kv.Version != 2 {
+		return path
+	}
+	return kv.join("data", path)
+}
+
+// MetadataPath returns the API path to list the secrets below the path and
+// to delete the secret with all its versions.
+func (kv KV) MetadataPath(path string) string {
+	if kv.Version != 2 {
+		return path
+	}
+	return kv.join("metadata", path)
+}
+
+// Policy grants full access to the path and everything below it.
+func (kv KV) Policy(path string) string {
+	if kv.Version != 2 {
+		return PathPolicy(path)
+	}
+	return PathPolicy(kv.DataPath(path)) + PathPolicy(kv.MetadataPath(path))
+}
+
+// Paths are the API paths of the path, to check policies against.
+func (kv KV) Paths(path string) []string {
+	if kv.Version != 2 {
+		return []string{path}
+	}
+	return []string{kv.DataPath(path), kv.MetadataPath(path)}
+}
+
+// Read reads the current version of the secret, nil if there is none.
+// Data of the version 2 engine is unwrapped.
+func (kv KV) Read(logical *api.Logical, path string) (map[string]interface{}, error) {
+	return kv.ReadVersion(logical, path, 0)
+}
+
+// ReadVersion reads the version of the secret, the current one if zero.
+func (kv KV) ReadVersion(logical *api.Logical, path string, version int) (map[string]interface{}, error) {
+	var sec *api.Secret
+	var err error
+	if version > 0 {
+		sec, err = logical.ReadWithData(kv.DataPath(path), map[string][]string{"version": {strconv.Itoa(version)}})
+	} else {
+		sec, err = logical.Read(kv.DataPath(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, nil
+	}
+	if kv.Version != 2 {
+		return sec.Data, nil
+	}
+
+	// deleted versions are returned with nil data
+	data, _ := sec.Data["data"].(map[string]interface{})
+	return data, nil
+}
+
+// Write writes the secret, it is wrapped into "data" for the version 2 engine.
+func (kv KV) Write(logical *api.Logical, path string, data map[string]interface{}) error {
+	if kv.Version == 2 {
+		data = map[string]interface{}{"data": data}
+	}
+	_, err := logical.Write(kv.DataPath(path), data)
+	return err
+}
+
+// WriteCAS writes the secret only if its current version is cas, 0 if
+// it doesn't exist. It is required by secrets with cas_required set,
+// cas is ignored by the version 1 engine.
+func (kv KV) WriteCAS(logical *api.Logical, path string, data map[string]interface{}, cas int) error {
+	if kv.Version != 2 {
+		return kv.Write(logical, path, data)
+	}
+	_, err := logical.Write(kv.DataPath(path), map[string]interface{}{
+		"options": map[string]interface{}{"cas": cas},
+		"data":    data,
+	})
+	return err
+}
+
+// Metadata of a secret in the version 2 engine.
+type Metadata struct {
+	// Versions are the versions of the secret that are neither deleted nor
+	// destroyed, oldest first.
+	Versions []int
+	// CurrentVersion is the latest version, deleted or not.
+	CurrentVersion int
+	// Settings are writable metadata settings: max_versions, cas_required,
+	// delete_version_after and custom_metadata.
+	Settings map[string]interface{}
+}
+
+// metadataSettings are settings of a secret that can be written back.
+var metadataSettings = []string{"max_versions", "cas_required", "delete_version_after", "custom_metadata"}
+
+// ReadMetadata reads metadata of the secret, nil for the version 1 engine.
+func (kv KV) ReadMetadata(logical *api.Logical, path string) (*Metadata, error) {
+	if kv.Version != 2 {
+		return nil, nil
+	}
+
+	sec, err := logical.Read(kv.MetadataPath(path))
+	if err != nil {
+		return nil, err
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, nil
+	}
+
+	md := &Metadata{Settings: make(map[string]interface{})}
+	for _, k := range metadataSettings {
+		if v, ok := sec.Data[k]; ok && v != nil {
+			md.Settings[k] = v
+		}
+	}
+
+	md.CurrentVersion, _ = strconv.Atoi(fmt.Sprint(sec.Data["current_version"]))
+
+	versions, _ := sec.Data["versions"].(map[string]interface{})
+	for k, v := range versions {
+		n, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		info, _ := v.(map[string]interface{})
+		if destroyed, _ := info["destroyed"].(bool); destroyed {
+			continue
+		}
+		if deleted, _ := info["deletion_time"].(string); deleted != "" {
+			continue
+		}
+		md.Versions = append(md.Versions, n)
+	}
+	sort.Ints(md.Versions)
+
+	return md, nil
+}
+
+// WriteMetadata writes metadata settings of the secret, it is a no-op for
+// the version 1 engine.
+func (kv KV) WriteMetadata(logical *api.Logical, path string, md *Metadata) error {
+	if kv.Version != 2 || md == nil || len(md.Settings) == 0 {
+		return nil
+	}
+	_, err := logical.Write(kv.MetadataPath(path), md.Settings)
+	return err
+}
+
+// List returns names of the secrets and directories directly below the path.
+func (kv KV) List(logical *api.Logical, path string) ([]string, error) {
+	list, err := logical.List(kv.MetadataPath(path) + "/")
+	if err != nil {
+		return nil, errors.Wrapf(err, "list %s", path)
+	}
+	if list == nil || list.Data == nil || list.Data["keys"] == nil {
+		return nil, nil
+	}
+
+	keys, ok := list.Data["keys"].([]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected keys list %v", list.Data["keys"])
+	}
+
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if name, ok := k.(string); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// Delete deletes the secret with all its versions and metadata.
+func (kv KV) Delete(logical *api.Logical, path string) error {
+	_, err := logical.Delete(kv.DeletePath(path))
+	return err
+}
+
+// DeletePath is the API path Delete deletes, the metadata path for the
+// version 2 engine so no version is kept.
+func (kv KV) DeletePath(path string) string {
+	if kv.Version == 2 {
+		return kv.MetadataPath(path)
+	}
+	return kv.DataPath(path)
+}
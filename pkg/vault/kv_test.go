@@ -0,0 +1,171 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestKVFromMounts(t *testing.T) {
+	mounts := map[string]*api.MountOutput{
+		"secret/":    {Type: "kv", Options: map[string]string{"version": "2"}},
+		"secret/v1/": {Type: "kv", Options: map[string]string{"version": "1"}},
+		"legacy/":    {Type: "generic"},
+		"transit/":   {Type: "transit"},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    KV
+		wantErr bool
+	}{
+		{
+			name: "kv version 2",
+			path: "secret/ns/name",
+			want: KV{Mount: "secret", Version: 2},
+		},
+		{
+			name: "most specific mount",
+			path: "secret/v1/ns/name",
+			want: KV{Mount: "secret/v1", Version: 1},
+		},
+		{
+			name: "generic",
+			path: "legacy/ns/name",
+			want: KV{Mount: "legacy", Version: 1},
+		},
+		{
+			name:    "not kv",
+			path:    "transit/keys/name",
+			wantErr: true,
+		},
+		{
+			name:    "prefix of a mount",
+			path:    "secrets/ns/name",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kvFromMounts(mounts, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("kvFromMounts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("kvFromMounts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKVPaths(t *testing.T) {
+	tests := []struct {
+		name         string
+		kv           KV
+		path         string
+		wantData     string
+		wantMetadata string
+	}{
+		{
+			name:         "version 1",
+			kv:           KV{Mount: "secret", Version: 1},
+			path:         "secret/ns/name",
+			wantData:     "secret/ns/name",
+			wantMetadata: "secret/ns/name",
+		},
+		{
+			name:         "version 2",
+			kv:           KV{Mount: "secret", Version: 2},
+			path:         "secret/ns/name/backup",
+			wantData:     "secret/data/ns/name/backup",
+			wantMetadata: "secret/metadata/ns/name/backup",
+		},
+		{
+			name:         "nested mount",
+			kv:           KV{Mount: "tenants/kv", Version: 2},
+			path:         "tenants/kv/ns/name",
+			wantData:     "tenants/kv/data/ns/name",
+			wantMetadata: "tenants/kv/metadata/ns/name",
+		},
+		{
+			name:         "mount itself",
+			kv:           KV{Mount: "secret", Version: 2},
+			path:         "secret",
+			wantData:     "secret/data",
+			wantMetadata: "secret/metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.kv.DataPath(tt.path); got != tt.wantData {
+				t.Errorf("DataPath() = %s, want %s", got, tt.wantData)
+			}
+			if got := tt.kv.MetadataPath(tt.path); got != tt.wantMetadata {
+				t.Errorf("MetadataPath() = %s, want %s", got, tt.wantMetadata)
+			}
+		})
+	}
+}
+
+func TestKVWriteCAS(t *testing.T) {
+	tests := []struct {
+		name     string
+		kv       KV
+		wantPath string
+		wantBody map[string]interface{}
+	}{
+		{
+			name:     "version 1",
+			kv:       KV{Mount: "secret", Version: 1},
+			wantPath: "/v1/secret/ns/name",
+			wantBody: map[string]interface{}{"key": "value"},
+		},
+		{
+			name:     "version 2",
+			kv:       KV{Mount: "secret", Version: 2},
+			wantPath: "/v1/secret/data/ns/name",
+			wantBody: map[string]interface{}{
+				"options": map[string]interface{}{"cas": float64(3)},
+				"data":    map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			var gotBody map[string]interface{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			cl, err := api.NewClient(&api.Config{Address: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.kv.WriteCAS(cl.Logical(), "secret/ns/name", map[string]interface{}{"key": "value"}, 3)
+			if err != nil {
+				t.Fatalf("WriteCAS() error = %v", err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("path = %s, want %s", gotPath, tt.wantPath)
+			}
+			if !reflect.DeepEqual(gotBody, tt.wantBody) {
+				t.Errorf("body = %v, want %v", gotBody, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/pkg/errors"
+)
+
+// SecretPath is where a tenant's secret keeps its data in Vault.
+func SecretPath(mountPoint, namespace, secretName string) string {
+	return fmt.Sprintf("%s/%s/%s", mountPoint, namespace, secretName)
+}
+
+// PathPolicy grants full access to the path and everything below it.
+func PathPolicy(path string) string {
+	return fmt.Sprintf(`
+path "%s"
+{
+  capabilities = ["create", "read", "update", "delete", "list"]
+}
+
+path "%s/*"
+{
+  capabilities = ["create", "read", "update", "delete", "list"]
+}
+`, path, path)
+}
+
+// CheckPolicyPaths returns an error if the policy can't be parsed or
+// grants access to anything outside of the paths.
+func CheckPolicyPaths(policy string, paths ...string) error {
+	root, err := hcl.Parse(policy)
+	if err != nil {
+		return errors.Wrap(err, "parse policy")
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return errors.New("policy doesn't contain an object")
+	}
+
+	for _, item := range list.Filter("path").Items {
+		if len(item.Keys) == 0 {
+			return errors.New("path without a name")
+		}
+
+		p, err := strconv.Unquote(item.Keys[0].Token.Text)
+		if err != nil {
+			p = item.Keys[0].Token.Text
+		}
+		within := false
+		for _, path := range paths {
+			within = within || withinPath(p, path)
+		}
+		if !within {
+			return errors.Errorf("path %q is outside of %s", p, strings.Join(paths, ", "))
+		}
+	}
+
+	return nil
+}
+
+func withinPath(p, path string) bool {
+	// a glob is only allowed for a whole path segment, "<path>*" would
+	// match siblings of the path as well
+	if strings.HasSuffix(p, "/*") {
+		p = strings.TrimSuffix(p, "/*")
+	}
+	if strings.ContainsAny(p, "*+") {
+		return false
+	}
+
+	return p == path || strings.HasPrefix(p, path+"/")
+}
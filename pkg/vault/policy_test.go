@@ -0,0 +1,64 @@
+package vault
+
+import (
+	"testing"
+)
+
+func TestCheckPolicyPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{
+			name:   "default policy",
+			policy: PathPolicy("secret/ns/name"),
+		},
+		{
+			name:   "nested path",
+			policy: `path "secret/ns/name/keys/*" { capabilities = ["read"] }`,
+		},
+		{
+			name:    "sibling glob",
+			policy:  `path "secret/ns/name*" { capabilities = ["read"] }`,
+			wantErr: true,
+		},
+		{
+			name:    "segment wildcard",
+			policy:  `path "secret/ns/+/keys" { capabilities = ["read"] }`,
+			wantErr: true,
+		},
+		{
+			name:    "other namespace",
+			policy:  `path "secret/other/name" { capabilities = ["read"] }`,
+			wantErr: true,
+		},
+		{
+			name:    "prefix of the name",
+			policy:  `path "secret/ns/name-other" { capabilities = ["read"] }`,
+			wantErr: true,
+		},
+		{
+			name: "one of paths outside",
+			policy: `
+path "secret/ns/name" { capabilities = ["read"] }
+path "sys/*" { capabilities = ["read"] }
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid hcl",
+			policy:  `path "secret/ns/name" {`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPolicyPaths(tt.policy, "secret/ns/name")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPolicyPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package vault
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+// KubernetesRole is a role of the Vault Kubernetes auth method bound to a
+// single ServiceAccount.
+type KubernetesRole struct {
+	// AuthPath is the auth method path, e.g. auth/kubernetes.
+	AuthPath       string
+	Name           string
+	ServiceAccount string
+	Namespace      string
+	Policy         string
+	Options        TokenOptions
+}
+
+// KubernetesAuthPath returns auth/<mountPath>, mountPath is "kubernetes" if empty.
+func KubernetesAuthPath(mountPath string) string {
+	if mountPath == "" {
+		mountPath = "kubernetes"
+	}
+
+	return "auth/" + strings.Trim(mountPath, "/")
+}
+
+// WriteKubernetesRole creates or updates the role.
+func WriteKubernetesRole(cl *api.Client, role KubernetesRole) error {
+	data := map[string]interface{}{
+		"bound_service_account_names":      []string{role.ServiceAccount},
+		"bound_service_account_namespaces": []string{role.Namespace},
+		"token_policies":                   []string{role.Policy},
+	}
+	if role.Options.TTL > 0 {
+		data["token_ttl"] = Duration(role.Options.TTL)
+	}
+	if role.Options.MaxTTL > 0 {
+		data["token_max_ttl"] = Duration(role.Options.MaxTTL)
+	}
+	if role.Options.Period > 0 {
+		data["token_period"] = Duration(role.Options.Period)
+	}
+
+	_, err := cl.Logical().Write(role.AuthPath+"/role/"+role.Name, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to write role")
+	}
+
+	return nil
+}
+
+// DeleteKubernetesRole deletes the role, missing roles are ignored.
+func DeleteKubernetesRole(cl *api.Client, authPath, name string) error {
+	_, err := cl.Logical().Delete(authPath + "/role/" + name)
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
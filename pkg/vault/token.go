@@ -0,0 +1,131 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+// TokenOptions defines lifetime of issued tokens. Zero values are
+// not sent to Vault, so the token role and mount defaults apply.
+type TokenOptions struct {
+	TTL    time.Duration
+	MaxTTL time.Duration
+	Period time.Duration
+}
+
+// CreateToken creates a renewable orphan token with the policy.
+func CreateToken(cl *api.Client, policyName string, opts TokenOptions) (*api.Secret, error) {
+	renewable := true
+	req := &api.TokenCreateRequest{
+		Policies:  []string{policyName},
+		Renewable: &renewable,
+		// issued tokens must not be revoked with the issuer's own token
+		// when it expires
+		NoParent: true,
+	}
+	if opts.TTL > 0 {
+		req.TTL = Duration(opts.TTL)
+	}
+	if opts.MaxTTL > 0 {
+		req.ExplicitMaxTTL = Duration(opts.MaxTTL)
+	}
+	if opts.Period > 0 {
+		req.Period = Duration(opts.Period)
+	}
+
+	sec, err := cl.Auth().Token().Create(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create token")
+	}
+
+	return sec, nil
+}
+
+// RevokeAccessor revokes the token only if it is still bound to the
+// policy, so a tampered accessor can't be used to revoke somebody else's
+// token. Tokens that are already expired or revoked are ignored.
+func RevokeAccessor(cl *api.Client, accessor, policyName string) error {
+	sec, err := cl.Auth().Token().LookupAccessor(accessor)
+	if IsInvalidAccessor(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "lookup accessor")
+	}
+
+	policies, err := sec.TokenPolicies()
+	if err != nil {
+		return errors.Wrap(err, "get token policies")
+	}
+
+	for _, p := range policies {
+		if p == policyName {
+			return cl.Auth().Token().RevokeAccessor(accessor)
+		}
+	}
+
+	return errors.Errorf("token is not bound to policy %s", policyName)
+}
+
+// IsInvalidAccessor reports whether the accessor lookup failed because the
+// token doesn't exist anymore.
+func IsInvalidAccessor(err error) bool {
+	respErr, ok := err.(*api.ResponseError)
+	return ok && respErr.StatusCode == http.StatusBadRequest
+}
+
+// IsNotFound reports whether Vault responded with 404.
+func IsNotFound(err error) bool {
+	respErr, ok := err.(*api.ResponseError)
+	return ok && respErr.StatusCode == http.StatusNotFound
+}
+
+// Duration formats d the way Vault accepts it in TTL fields.
+func Duration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
+
+// SecondsFrom converts a number of seconds from a Vault response.
+func SecondsFrom(v interface{}) (time.Duration, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, errors.Errorf("unexpected value %v", v)
+	}
+
+	s, err := n.Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(s) * time.Second, nil
+}
+
+// CheckCapabilities returns an error if the client's token lacks any of
+// the capabilities on the path.
+func CheckCapabilities(cl *api.Client, path string, capabilities ...string) error {
+	granted, err := cl.Sys().CapabilitiesSelf(path)
+	if err != nil {
+		return errors.Wrapf(err, "get capabilities on %s", path)
+	}
+
+	has := make(map[string]bool, len(granted))
+	for _, c := range granted {
+		has[c] = true
+	}
+	if has["root"] {
+		return nil
+	}
+
+	for _, c := range capabilities {
+		if !has[c] {
+			return errors.Errorf("token has no %s capability on %s", c, path)
+		}
+	}
+
+	return nil
+}